@@ -0,0 +1,181 @@
+package settings
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	gzipExtension  = ".gz"
+	zipExtension   = ".zip"
+	tarExtension   = ".tar"
+	tarGzExtension = ".tar.gz"
+	tgzExtension   = ".tgz"
+)
+
+type archiveMember struct {
+	name    string
+	content []byte
+}
+
+func isArchive(fileName string) bool {
+	name := strings.ToLower(fileName)
+	for _, ext := range []string{zipExtension, tarExtension, tarGzExtension, tgzExtension} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGzip(fileName string) bool {
+	return strings.ToLower(filepath.Ext(fileName)) == gzipExtension && !isArchive(fileName)
+}
+
+// isSettingsFile reports whether a file can be merged: either its extension is supported,
+// or it is a gzip-compressed file of a supported extension.
+func isSettingsFile(fileName string) bool {
+	if isGzip(fileName) {
+		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+	return supportedExtension(strings.ToLower(filepath.Ext(fileName))).validateExtension()
+}
+
+func gunzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return ioutil.ReadAll(r)
+}
+
+// mergeArchive merges the settings files of a tar or zip archive,
+// in the same order as the files of a directory are merged. Hidden files and editor backups are skipped.
+func (s *Settings) mergeArchive(fileName string, b []byte) error {
+	members, err := readArchive(fileName, b)
+	if err != nil {
+		return fmt.Errorf("%s: %s", fileName, err)
+	}
+
+	file, include := s.merging.origin.File, s.merging.include
+	defer func() {
+		s.merging.origin.File, s.merging.include = file, include
+	}()
+	s.merging.include = nil
+
+	for _, m := range members {
+		s.merging.origin.File = fileName + "/" + m.name
+		if err := s.mergeFile(m.name, m.content); err != nil {
+			return fmt.Errorf("%s: %s: %s", fileName, m.name, err)
+		}
+	}
+	return nil
+}
+
+func readArchive(fileName string, b []byte) ([]archiveMember, error) {
+	var (
+		members []archiveMember
+		err     error
+	)
+
+	if strings.HasSuffix(strings.ToLower(fileName), zipExtension) {
+		members, err = readZip(b)
+	} else {
+		members, err = readTar(fileName, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kept := members[:0]
+	for _, m := range members {
+		if !isIgnoredPath(m.name) {
+			kept = append(kept, m)
+		}
+	}
+	members = kept
+
+	sort.Slice(members, func(i, j int) bool {
+		return walkOrderLess(members[i].name, members[j].name)
+	})
+	return members, nil
+}
+
+func readZip(b []byte) ([]archiveMember, error) {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	var members []archiveMember
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isSettingsFile(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, archiveMember{name: path.Clean(f.Name), content: content})
+	}
+	return members, nil
+}
+
+func readTar(fileName string, b []byte) ([]archiveMember, error) {
+	if !strings.HasSuffix(strings.ToLower(fileName), tarExtension) {
+		var err error
+		if b, err = gunzip(b); err != nil {
+			return nil, err
+		}
+	}
+
+	var members []archiveMember
+	r := tar.NewReader(bytes.NewReader(b))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg || !isSettingsFile(h.Name) {
+			continue
+		}
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, archiveMember{name: path.Clean(h.Name), content: content})
+	}
+	return members, nil
+}
+
+// walkOrderLess orders slash separated paths the same way as filepath.Walk visits them:
+// the path elements are compared one by one in lexical order.
+func walkOrderLess(a, b string) bool {
+	as := strings.Split(strings.TrimPrefix(a, "./"), "/")
+	bs := strings.Split(strings.TrimPrefix(b, "./"), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,181 @@
+package settings
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitArchivesSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitArchivesSuite) TestGzip() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testYamlGzFilePAth, gzipHelper(u, []byte(testYamlContentOther)), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testYamlGzFilePAth)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("other.content.string")
+	u.Equal(nil, err)
+	u.Equal("text", v)
+
+	sm = New(testFilePAth)
+	u.Equal(nil, sm.Error)
+
+	fileNames, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{"settings/other.yaml.gz", "settings/test.yaml"}, fileNames)
+
+	err = ioutil.WriteFile(testYamlGzFilePAth, []byte(testYamlContentOther), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm = New(testYamlGzFilePAth)
+	u.Equal("./settings/other.yaml.gz: gzip: invalid header", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitArchivesSuite) TestTar() {
+	initTestOk()
+
+	b := tarHelper(u, testArchiveMembers)
+	err := ioutil.WriteFile(testTarFilePAth, b, os.ModePerm)
+	u.Equal(nil, err)
+	err = ioutil.WriteFile(testTarGzFilePAth, gzipHelper(u, b), os.ModePerm)
+	u.Equal(nil, err)
+
+	for _, file := range []string{testTarFilePAth, testTarGzFilePAth} {
+		sm := New(testYamlFilePAth).Merge(file)
+		u.Equal(nil, sm.Error)
+		u.assertArchiveMembers(sm)
+
+		fileNames, err := sm.GetSettingsFileNames()
+		u.Equal(nil, err)
+		u.Equal([]string{"settings/test.yaml", file[2:]}, fileNames)
+	}
+
+	resetTest()
+}
+
+func (u unitArchivesSuite) TestZip() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testZipFilePAth, zipHelper(u, testArchiveMembers), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testZipFilePAth)
+	u.Equal(nil, sm.Error)
+	u.assertArchiveMembers(sm)
+
+	err = ioutil.WriteFile(testZipFilePAth, []byte("not a zip"), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm = New(testZipFilePAth)
+	u.Equal("./settings/bundle.zip: zip: not a valid zip file", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitArchivesSuite) TestWalkOrderLess() {
+	u.Equal(true, walkOrderLess("a/z.yaml", "a.yaml"))
+	u.Equal(true, walkOrderLess("a.yaml", "b/a.yaml"))
+	u.Equal(true, walkOrderLess("a", "a/b.yaml"))
+	u.Equal(false, walkOrderLess("b.yaml", "a/b.yaml"))
+}
+
+func (u unitArchivesSuite) assertArchiveMembers(sm *Settings) {
+	v, err := sm.Get("bundle.order")
+	u.Equal(nil, err)
+	u.Equal("last", v)
+
+	v, err = sm.Get("bundle.nested")
+	u.Equal(nil, err)
+	u.Equal(true, v)
+
+	v, err = sm.Get("bundle.compressed")
+	u.Equal(nil, err)
+	u.Equal(true, v)
+
+	isSet, err := sm.IsSet("readme")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+}
+
+func TestArchivesUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitArchivesSuite))
+}
+
+func gzipHelper(u unitArchivesSuite, b []byte) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, err := w.Write(b)
+	u.Equal(nil, err)
+	u.Equal(nil, w.Close())
+	return buf.Bytes()
+}
+
+func tarHelper(u unitArchivesSuite, members []archiveMember) []byte {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	for _, m := range members {
+		content := m.content
+		if len(m.name) > 3 && m.name[len(m.name)-3:] == ".gz" {
+			content = gzipHelper(u, content)
+		}
+		err := w.WriteHeader(&tar.Header{Name: m.name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		u.Equal(nil, err)
+		_, err = w.Write(content)
+		u.Equal(nil, err)
+	}
+	u.Equal(nil, w.Close())
+	return buf.Bytes()
+}
+
+func zipHelper(u unitArchivesSuite, members []archiveMember) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, m := range members {
+		content := m.content
+		if len(m.name) > 3 && m.name[len(m.name)-3:] == ".gz" {
+			content = gzipHelper(u, content)
+		}
+		f, err := w.Create(m.name)
+		u.Equal(nil, err)
+		_, err = f.Write(content)
+		u.Equal(nil, err)
+	}
+	u.Equal(nil, w.Close())
+	return buf.Bytes()
+}
+
+var (
+	testArchiveMembers = []archiveMember{
+		{name: "b.yaml", content: []byte("bundle:\n  order: last")},
+		{name: "a.json", content: []byte(`{"bundle": {"order": "first"}}`)},
+		{name: "a/nested.yaml", content: []byte("bundle:\n  order: nested\n  nested: true")},
+		{name: "a/rules.yaml.gz", content: []byte("bundle:\n  compressed: true")},
+		{name: "README.md", content: []byte("readme: true")},
+		{name: ".hidden/z.yaml", content: []byte("bundle:\n  order: hidden")},
+	}
+
+	testYamlGzFilePAth = "./settings/other.yaml.gz"
+
+	testTarFilePAth = "./settings/bundle.tar"
+
+	testTarGzFilePAth = "./settings/bundle.tar.gz"
+
+	testZipFilePAth = "./settings/bundle.zip"
+)
@@ -0,0 +1,245 @@
+package settings
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures the offline cache of a remote source.
+// The last successful payload of the source is stored in the cache file,
+// which is loaded instead of the source, when it cannot be reached.
+type CacheOptions struct {
+	// File of the cache. If empty, the source is not cached.
+	File string
+
+	// Key encrypts the cache file with AES-GCM, if set. It must be 16, 24 or 32 bytes long.
+	Key []byte
+}
+
+// Health describes, whether the settings are served by the remote sources themselves.
+type Health struct {
+	// Offline lists the remote sources, which are served from their offline cache.
+	Offline []OfflineSource
+}
+
+// OfflineSource is a remote source, which cannot be reached, so it is served from its offline cache.
+type OfflineSource struct {
+	// Name of the source, e.g. the URL of it.
+	Name string
+
+	// Error of the remote, why it cannot be reached.
+	Error error
+
+	// CachedAt is the time, when the payload of the cache was stored.
+	CachedAt time.Time
+}
+
+// Healthy reports whether no remote source is served from its offline cache.
+func (h Health) Healthy() bool {
+	return len(h.Offline) == 0
+}
+
+// GetHealth returns the health of the remote sources, see CacheOptions.
+func (s *Settings) GetHealth() (Health, error) {
+	if s.Error != nil {
+		return Health{}, fmt.Errorf("settings.GetHealth :: %s", s.Error)
+	}
+
+	s.mux.Lock()
+	layers := s.layers
+	s.mux.Unlock()
+
+	var h Health
+	for _, l := range layers {
+		if o, ok := l.source.(offlineReporter); ok {
+			if offline, ok := o.offline(); ok {
+				h.Offline = append(h.Offline, offline)
+			}
+		}
+	}
+	return h, nil
+}
+
+// offlineReporter is implemented by the sources, which can be served from an offline cache.
+type offlineReporter interface {
+	offline() (OfflineSource, bool)
+}
+
+type cachedSource struct {
+	remoteSource
+	opts CacheOptions
+
+	mux      sync.Mutex
+	fallback *OfflineSource
+}
+
+// withCache wraps a remote source with an offline cache, if a cache file is given.
+func withCache(src remoteSource, opts CacheOptions) remoteSource {
+	if opts.File == "" {
+		return src
+	}
+	return &cachedSource{remoteSource: src, opts: opts}
+}
+
+func (c *cachedSource) load() (map[string]interface{}, error) {
+	m, err := c.remoteSource.load()
+	if err == nil {
+		c.mux.Lock()
+		c.fallback = nil
+		c.mux.Unlock()
+
+		if err := c.write(m); err != nil {
+			log.Println("settings.Cache", err)
+		}
+		return m, nil
+	}
+
+	cached, cachedAt, cacheErr := c.read()
+	if cacheErr != nil {
+		if os.IsNotExist(cacheErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s, cache: %s", err, cacheErr)
+	}
+
+	c.mux.Lock()
+	c.fallback = &OfflineSource{Name: c.name(), Error: err, CachedAt: cachedAt}
+	c.mux.Unlock()
+
+	return cached, nil
+}
+
+func (c *cachedSource) offline() (OfflineSource, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.fallback == nil {
+		return OfflineSource{}, false
+	}
+	return *c.fallback, true
+}
+
+func (c *cachedSource) write(m map[string]interface{}) error {
+	f, _ := formats.byName("yaml")
+	b, err := f.encode(m)
+	if err != nil {
+		return err
+	}
+	if b, err = c.seal(b); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.opts.File, b, 0600)
+}
+
+func (c *cachedSource) read() (map[string]interface{}, time.Time, error) {
+	fi, err := os.Stat(c.opts.File)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	b, err := ioutil.ReadFile(c.opts.File)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if b, err = c.open(b); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	f, _ := formats.byName("yaml")
+	m, err := f.decode(b)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return m, fi.ModTime(), nil
+}
+
+func (c *cachedSource) seal(b []byte) ([]byte, error) {
+	if len(c.opts.Key) == 0 {
+		return b, nil
+	}
+	gcm, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, b, nil), nil
+}
+
+func (c *cachedSource) open(b []byte) ([]byte, error) {
+	if len(c.opts.Key) == 0 {
+		return b, nil
+	}
+	gcm, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < gcm.NonceSize() {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+	return gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+}
+
+func (c *cachedSource) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic writes a file through a temporary file in the same directory,
+// so that readers see either the previous or the new content of it.
+func writeFileAtomic(file string, b []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(file))
+}
+
+// syncDir flushes a directory, so that a file renamed into it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+	return d.Sync()
+}
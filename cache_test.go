@@ -0,0 +1,117 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitCacheSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitCacheSuite) TestURLCache() {
+	initTestOk()
+
+	ts := &testHTTPServer{content: "service:\n  name: Remote\nserver:\n  port: 8080"}
+	server := httptest.NewServer(ts)
+	rawURL := server.URL + "/settings.yaml"
+	opts := URLOptions{Cache: CacheOptions{File: testCacheFilePath}}
+
+	sm := NewFromURL(rawURL, opts)
+	u.Equal(nil, sm.Error)
+
+	health, err := sm.GetHealth()
+	u.Equal(nil, err)
+	u.Equal(true, health.Healthy())
+
+	b, err := ioutil.ReadFile(testCacheFilePath)
+	u.Equal(nil, err)
+	u.Contains(string(b), "Remote")
+
+	server.Close()
+
+	sm = NewFromURL(rawURL, opts)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Remote", v)
+
+	i, err := sm.GetInt("server.port")
+	u.Equal(nil, err)
+	u.Equal(8080, i)
+
+	health, err = sm.GetHealth()
+	u.Equal(nil, err)
+	u.Equal(false, health.Healthy())
+	u.Equal(1, len(health.Offline))
+	u.Equal(rawURL, health.Offline[0].Name)
+	u.Contains(fmt.Sprint(health.Offline[0].Error), "connection refused")
+	u.Equal(false, health.Offline[0].CachedAt.IsZero())
+
+	sm = NewFromURL(rawURL, URLOptions{Cache: CacheOptions{File: filepath.Join(testDirPath, "missing.cache")}})
+	u.Equal(true, strings.HasPrefix(fmt.Sprint(sm.Error), "settings.NewFromURL :: Get "))
+
+	_, err = NewFromContent("a: 1").GetHealth()
+	u.Equal(nil, err)
+
+	resetTest()
+}
+
+func (u unitCacheSuite) TestEncryptedCache() {
+	initTestOk()
+
+	ts := &testHTTPServer{content: "secret:\n  password: s3cr3t"}
+	server := httptest.NewServer(ts)
+	rawURL := server.URL + "/settings.yaml"
+	key := []byte("0123456789abcdef")
+
+	sm := New(testYamlFilePAth).MergeURL(rawURL, URLOptions{Cache: CacheOptions{File: testCacheFilePath, Key: key}})
+	u.Equal(nil, sm.Error)
+
+	b, err := ioutil.ReadFile(testCacheFilePath)
+	u.Equal(nil, err)
+	u.NotContains(string(b), "s3cr3t")
+
+	fi, err := os.Stat(testCacheFilePath)
+	u.Equal(nil, err)
+	u.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+	server.Close()
+
+	sm = New(testYamlFilePAth).MergeURL(rawURL, URLOptions{Cache: CacheOptions{File: testCacheFilePath, Key: key}})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("secret.password")
+	u.Equal(nil, err)
+	u.Equal("s3cr3t", v)
+
+	health, err := sm.GetHealth()
+	u.Equal(nil, err)
+	u.Equal(false, health.Healthy())
+
+	sm = New(testYamlFilePAth).MergeURL(rawURL, URLOptions{Cache: CacheOptions{File: testCacheFilePath, Key: []byte("fedcba9876543210")}})
+	u.Equal(true, strings.HasSuffix(fmt.Sprint(sm.Error), "cache: cipher: message authentication failed"))
+
+	_, err = sm.GetHealth()
+	u.Equal(true, strings.HasPrefix(fmt.Sprint(err), "settings.GetHealth :: settings.MergeURL :: "))
+
+	resetTest()
+}
+
+func TestCacheUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitCacheSuite))
+}
+
+var (
+	testCacheFilePath = "./settings/remote.cache"
+)
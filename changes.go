@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const changesSize = 16
+
+// Change describes, how a reload changed the settings. The keys are dotted paths of the leaf values, sorted.
+type Change struct {
+	// Added are the keys set by the reload.
+	Added []string
+
+	// Changed are the keys, whose values were changed by the reload.
+	Changed []string
+
+	// Removed are the keys, which are no longer set, e.g. deleted by a delete marker of a merged file.
+	Removed []string
+}
+
+// Changes returns a channel, which receives the changes made by Reload, also when it is called by AutoReload.
+// A reload, which does not change the settings, is not reported. Changes are dropped while the channel is full.
+func (s *Settings) Changes() <-chan Change {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.changes == nil {
+		s.changes = make(chan Change, changesSize)
+	}
+	return s.changes
+}
+
+// reportChange sends the differences of the settings before and after a reload to the changes channel.
+func (s *Settings) reportChange(before, after map[string]interface{}) {
+	if s.changes == nil {
+		return
+	}
+
+	c, ok := diffSettings(before, after)
+	if !ok {
+		return
+	}
+
+	select {
+	case s.changes <- c:
+	default:
+	}
+}
+
+// diffSettings returns the differences of two settings, and reports whether they differ.
+func diffSettings(before, after map[string]interface{}) (Change, bool) {
+	old, current := flattenSettings(before, nil, nil), flattenSettings(after, nil, nil)
+
+	var c Change
+	for k, v := range current {
+		if o, ok := old[k]; !ok {
+			c.Added = append(c.Added, k)
+		} else if !reflect.DeepEqual(o, v) {
+			c.Changed = append(c.Changed, k)
+		}
+	}
+	for k := range old {
+		if _, ok := current[k]; !ok {
+			c.Removed = append(c.Removed, k)
+		}
+	}
+
+	sort.Strings(c.Added)
+	sort.Strings(c.Changed)
+	sort.Strings(c.Removed)
+	return c, len(c.Added)+len(c.Changed)+len(c.Removed) > 0
+}
+
+// flattenSettings returns the leaf values of settings by their dotted keys.
+func flattenSettings(m map[string]interface{}, path []string, leaves map[string]interface{}) map[string]interface{} {
+	if leaves == nil {
+		leaves = map[string]interface{}{}
+	}
+	for k, v := range m {
+		p := append(path[:len(path):len(path)], k)
+		if sub, ok := toStringMap(v); ok && len(sub) > 0 {
+			flattenSettings(sub, p, leaves)
+			continue
+		}
+		leaves[strings.Join(p, ".")] = v
+	}
+	return leaves
+}
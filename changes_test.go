@@ -0,0 +1,64 @@
+package settings
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitChangesSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitChangesSuite) TestChanges() {
+	initTestOk()
+
+	overlay := filepath.Join(testDirPath, "overlay.yaml")
+	u.Equal(nil, ioutil.WriteFile(overlay, []byte("service:\n  name: Overlay\n"), 0644))
+
+	sm := New(testYamlFilePAth).Merge(overlay)
+	u.Equal(nil, sm.Error)
+	changes := sm.Changes()
+
+	sm.Reload()
+	u.Equal(0, len(changes))
+
+	u.Equal(nil, ioutil.WriteFile(overlay, []byte(`
+service:
+  name: Changed
+  id: S-1
+email:
+  server: !unset
+`), 0644))
+	sm.Reload()
+
+	u.Equal(1, len(changes))
+	u.Equal(Change{
+		Added:   []string{"service.id"},
+		Changed: []string{"service.name"},
+		Removed: []string{"email.server.address", "email.server.port", "email.server.user"},
+	}, <-changes)
+
+	u.Equal(nil, ioutil.WriteFile(overlay, []byte("service: {name: [invalid\n"), 0644))
+	sm.Reload()
+	u.Equal(0, len(changes))
+
+	resetTest()
+}
+
+func (u unitChangesSuite) TestDiffSettings() {
+	_, ok := diffSettings(map[string]interface{}{"a": map[string]interface{}{"b": 1}}, map[string]interface{}{"a": map[string]interface{}{"b": 1}})
+	u.Equal(false, ok)
+
+	c, ok := diffSettings(map[string]interface{}{"a": map[string]interface{}{"b": 1}}, map[string]interface{}{"a": 1})
+	u.Equal(true, ok)
+	u.Equal(Change{Added: []string{"a"}, Removed: []string{"a.b"}}, c)
+}
+
+func TestChangesUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitChangesSuite))
+}
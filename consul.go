@@ -0,0 +1,237 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultConsulAddress  = "http://127.0.0.1:8500"
+	defaultConsulWaitTime = 5 * time.Minute
+	consulRetryDelay      = time.Second
+)
+
+// ConsulOptions configures a Consul KV settings source.
+type ConsulOptions struct {
+	// Address of the Consul HTTP API, defaults to http://127.0.0.1:8500.
+	Address string
+
+	// Token is sent as X-Consul-Token, Datacenter is sent as the dc parameter.
+	Token      string
+	Datacenter string
+
+	// ContentType is the type of the value, e.g. "json" or "yaml", when the key holds a single blob.
+	// If empty, the keys under the prefix are mapped to nested settings, e.g. "app/db/host" becomes "db.host".
+	ContentType string
+
+	// WaitTime of the blocking queries used by AutoReload, defaults to 5 minutes.
+	WaitTime time.Duration
+
+	// Client is used to send the requests, defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Cache stores the last successful payload, which is loaded when the source cannot be reached.
+	Cache CacheOptions
+}
+
+type consulEntry struct {
+	Key   string
+	Value []byte
+}
+
+type consulSource struct {
+	prefix string
+	opts   ConsulOptions
+
+	mux     sync.Mutex
+	index   uint64
+	entries []consulEntry
+}
+
+// NewFromConsul initializes settings from a key or a key prefix of Consul KV.
+func NewFromConsul(prefix string, opts ConsulOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeRemote(withCache(newConsulSource(prefix, opts), opts.Cache), "NewFromConsul")
+}
+
+// MergeConsul merges initialized settings with a key or a key prefix of Consul KV.
+// AutoReload watches the keys with blocking queries, and reloads the settings when they change.
+func (s *Settings) MergeConsul(prefix string, opts ConsulOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeRemote(withCache(newConsulSource(prefix, opts), opts.Cache), "MergeConsul")
+}
+
+func newConsulSource(prefix string, opts ConsulOptions) *consulSource {
+	if opts.Address == "" {
+		opts.Address = defaultConsulAddress
+	}
+	if opts.WaitTime <= 0 {
+		opts.WaitTime = defaultConsulWaitTime
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &consulSource{prefix: strings.Trim(prefix, "/"), opts: opts}
+}
+
+func (c *consulSource) name() string {
+	return "consul:" + c.prefix
+}
+
+func (c *consulSource) load() (map[string]interface{}, error) {
+	index, entries, err := c.query(context.Background(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	c.index, c.entries = index, entries
+	c.mux.Unlock()
+
+	return c.decode(entries)
+}
+
+func (c *consulSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for ctx.Err() == nil {
+		c.mux.Lock()
+		index := c.index
+		c.mux.Unlock()
+
+		newIndex, entries, err := c.query(ctx, index)
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			report(err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(consulRetryDelay):
+			}
+			continue
+		}
+
+		c.mux.Lock()
+		if newIndex < c.index {
+			// The index went backwards, e.g. after a snapshot restore: start over.
+			newIndex = 0
+		}
+		changed := !reflect.DeepEqual(c.entries, entries)
+		c.index, c.entries = newIndex, entries
+		c.mux.Unlock()
+
+		if changed {
+			reload()
+		}
+	}
+}
+
+// key returns the key queried. The keys under a prefix are read by the prefix ending in "/",
+// so that a recursive read does not match the siblings sharing the prefix, e.g. "apple" of "app".
+func (c *consulSource) key() string {
+	if c.opts.ContentType == "" && c.prefix != "" {
+		return c.prefix + "/"
+	}
+	return c.prefix
+}
+
+// query reads the entries of the prefix, as a blocking query if index is greater than zero.
+func (c *consulSource) query(ctx context.Context, index uint64) (uint64, []consulEntry, error) {
+	params := url.Values{}
+	if c.opts.ContentType == "" {
+		params.Set("recurse", "true")
+	}
+	if c.opts.Datacenter != "" {
+		params.Set("dc", c.opts.Datacenter)
+	}
+	if index > 0 {
+		params.Set("index", strconv.FormatUint(index, 10))
+		params.Set("wait", fmt.Sprintf("%ds", int(c.opts.WaitTime.Seconds())))
+	}
+
+	rawURL := fmt.Sprintf("%s/v1/kv/%s?%s", strings.TrimRight(c.opts.Address, "/"), c.key(), params.Encode())
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.opts.Token != "" {
+		req.Header.Set("X-Consul-Token", c.opts.Token)
+	}
+
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		if c.opts.ContentType != "" {
+			return 0, nil, fmt.Errorf("consul: %s :: cannot find key", c.prefix)
+		}
+		return newIndex, nil, nil
+	default:
+		return 0, nil, fmt.Errorf("consul: %s :: unexpected status: %s", c.prefix, resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	var entries []consulEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return 0, nil, fmt.Errorf("consul: %s :: %s", c.prefix, err)
+	}
+	return newIndex, entries, nil
+}
+
+func (c *consulSource) decode(entries []consulEntry) (map[string]interface{}, error) {
+	if c.opts.ContentType != "" {
+		f, ok := formats.byName(c.opts.ContentType)
+		if !ok {
+			return nil, fmt.Errorf("consul: %s :: unsupported content type: %s", c.prefix, c.opts.ContentType)
+		}
+		if len(entries) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		return f.decode(entries[0].Value)
+	}
+
+	m := map[string]interface{}{}
+	for _, entry := range entries {
+		if c.prefix != "" && entry.Key != c.prefix && !strings.HasPrefix(entry.Key, c.prefix+"/") {
+			continue
+		}
+		key := strings.Trim(strings.TrimPrefix(entry.Key, c.prefix), "/")
+		if key == "" || strings.HasSuffix(entry.Key, "/") {
+			continue
+		}
+		setNested(m, strings.Split(key, "/"), inferScalar(string(entry.Value)))
+	}
+	return m, nil
+}
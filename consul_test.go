@@ -0,0 +1,187 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitConsulSuite struct {
+		suite.Suite
+	}
+
+	testConsulServer struct {
+		mux     sync.Mutex
+		kv      map[string]string
+		index   uint64
+		changed chan struct{}
+		token   string
+	}
+)
+
+func (u unitConsulSuite) TestNewFromConsulTree() {
+	ts := newTestConsulServer(map[string]string{
+		"app/db/host":    "db.example.com",
+		"app/db/port":    "5432",
+		"app/debug":      "true",
+		"app/":           "",
+		"other/db/host":  "other.example.com",
+		"app/ratio/rate": "0.25",
+		"apple/color":    "red",
+	})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := NewFromConsul("app", ConsulOptions{Address: server.URL, Token: "secret"})
+	u.Equal(nil, sm.Error)
+	u.Equal("secret", ts.token)
+
+	v, err := sm.GetString("db.host")
+	u.Equal(nil, err)
+	u.Equal("db.example.com", v)
+
+	i, err := sm.GetInt("db.port")
+	u.Equal(nil, err)
+	u.Equal(5432, i)
+
+	b, err := sm.GetBool("debug")
+	u.Equal(nil, err)
+	u.Equal(true, b)
+
+	f, err := sm.GetFloat64("ratio.rate")
+	u.Equal(nil, err)
+	u.Equal(0.25, f)
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	sort.Strings(keys)
+	u.Equal([]string{"db.host", "db.port", "debug", "ratio.rate"}, keys)
+}
+
+func (u unitConsulSuite) TestMergeConsulBlob() {
+	initTestOk()
+
+	ts := newTestConsulServer(map[string]string{
+		"config/app.yaml": "service:\n  name: FromConsul",
+	})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := New(testYamlFilePAth).MergeConsul("config/app.yaml", ConsulOptions{Address: server.URL, ContentType: "yaml"})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("FromConsul", v)
+
+	v, err = sm.Get("email.to")
+	u.Equal(nil, err)
+	u.Equal("user@gmail.com", v)
+
+	sm = NewFromConsul("config/missing.yaml", ConsulOptions{Address: server.URL, ContentType: "yaml"})
+	u.Equal("settings.NewFromConsul :: consul: config/missing.yaml :: cannot find key", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitConsulSuite) TestConsulAutoReload() {
+	ts := newTestConsulServer(map[string]string{"app/level": "info"})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	reloaded := make(chan struct{}, 1)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := NewFromConsul("app", ConsulOptions{Address: server.URL, WaitTime: time.Second})
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+
+	ts.put("other/level", "ignored")
+	ts.put("app/level", "debug")
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+	sm.StopAutoReload()
+
+	v, err := sm.Get("level")
+	u.Equal(nil, err)
+	u.Equal("debug", v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+}
+
+func TestConsulUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitConsulSuite))
+}
+
+func newTestConsulServer(kv map[string]string) *testConsulServer {
+	return &testConsulServer{kv: kv, index: 1, changed: make(chan struct{})}
+}
+
+func (ts *testConsulServer) put(key, value string) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	ts.kv[key] = value
+	ts.index++
+	close(ts.changed)
+	ts.changed = make(chan struct{})
+}
+
+// ServeHTTP is a minimal stand-in of the GET /v1/kv/<key> endpoint of Consul, including blocking queries.
+func (ts *testConsulServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	query := r.URL.Query()
+
+	ts.mux.Lock()
+	ts.token = r.Header.Get("X-Consul-Token")
+	if index, _ := strconv.ParseUint(query.Get("index"), 10, 64); index > 0 && index >= ts.index {
+		wait, _ := time.ParseDuration(query.Get("wait"))
+		changed := ts.changed
+		ts.mux.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+		ts.mux.Lock()
+	}
+	defer ts.mux.Unlock()
+
+	var entries []map[string]interface{}
+	for k, v := range ts.kv {
+		if k == key || (query.Get("recurse") != "" && strings.HasPrefix(k, key)) {
+			entries = append(entries, map[string]interface{}{"Key": k, "Value": []byte(v), "ModifyIndex": ts.index})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i]["Key"].(string) < entries[j]["Key"].(string)
+	})
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(ts.index, 10))
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(entries)
+}
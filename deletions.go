@@ -0,0 +1,154 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// deleteMarkerKey marks an object of a document to delete the key holding it from the settings merged before,
+// e.g. {"db": {"$delete": true}}. The other keys of a marked object are merged as the new value of the key.
+const deleteMarkerKey = "$delete"
+
+// unsetTag marks a key of a YAML document to be deleted, e.g. "db: !unset".
+const unsetTag = "!unset"
+
+// deleteMarked deletes the keys marked by a document from the settings data,
+// and returns a copy of the document without the markers.
+func (s *Settings) deleteMarked(doc map[string]interface{}) (map[string]interface{}, error) {
+	var deleted [][]string
+	v, err := stripDeleteMarkers(doc, nil, &deleted)
+	if err != nil {
+		return nil, err
+	}
+	doc = v.(map[string]interface{})
+	if len(deleted) == 0 {
+		return doc, nil
+	}
+
+	all := s.Data.AllSettings()
+	for _, path := range deleted {
+		s.recordDeletion(path)
+		if len(path) == 0 {
+			all = map[string]interface{}{}
+			continue
+		}
+		deleteNested(all, path)
+	}
+
+	data := viper.New()
+	if err := data.MergeConfigMap(all); err != nil {
+		return nil, err
+	}
+	s.Data = data
+	s.applyOverrides()
+	return doc, nil
+}
+
+// stripDeleteMarkers returns a copy of a value without the delete markers, and collects the paths marked by them.
+func stripDeleteMarkers(value interface{}, path []string, deleted *[][]string) (interface{}, error) {
+	m, ok := toStringMap(value)
+	if !ok {
+		return value, nil
+	}
+
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != deleteMarkerKey {
+			c[k] = v
+			continue
+		}
+		marked, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s :: should be a boolean", strings.Join(append(path[:len(path):len(path)], k), "."))
+		}
+		if marked {
+			*deleted = append(*deleted, path)
+		}
+	}
+
+	for k, v := range c {
+		stripped, err := stripDeleteMarkers(v, append(path[:len(path):len(path)], strings.ToLower(k)), deleted)
+		if err != nil {
+			return nil, err
+		}
+		c[k] = stripped
+	}
+	return c, nil
+}
+
+// deleteNested deletes a nested key of a map, e.g. ["db", "host"] deletes m["db"]["host"].
+func deleteNested(m map[string]interface{}, path []string) {
+	for _, k := range path[:len(path)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = next
+	}
+	delete(m, path[len(path)-1])
+}
+
+// resolveUnsetTags replaces the values tagged by "!unset" with delete markers in a YAML stream.
+// The decoder of the settings drops the tags, so they are resolved before decoding.
+func resolveUnsetTags(content []byte) ([]byte, error) {
+	if !bytes.Contains(content, []byte(unsetTag)) {
+		return content, nil
+	}
+
+	var (
+		out   bytes.Buffer
+		found bool
+	)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	enc := yaml.NewEncoder(&out)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if replaceUnsetTags(&doc) {
+			found = true
+		}
+		if err := enc.Encode(&doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return content, nil
+	}
+	return out.Bytes(), nil
+}
+
+func replaceUnsetTags(n *yaml.Node) bool {
+	if n.Tag == unsetTag {
+		*n = yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: deleteMarkerKey},
+				{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"},
+			},
+		}
+		return true
+	}
+
+	found := false
+	for _, c := range n.Content {
+		if replaceUnsetTags(c) {
+			found = true
+		}
+	}
+	return found
+}
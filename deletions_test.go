@@ -0,0 +1,105 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitDeletionsSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitDeletionsSuite) TestUnsetTag() {
+	initTestOk()
+
+	overlay := filepath.Join(testDirPath, "overlay.yaml")
+	u.Equal(nil, ioutil.WriteFile(overlay, []byte(`
+email: !unset
+server:
+  timeout:
+    read: !unset
+    write: 10
+`), 0644))
+
+	sm := New(testYamlFilePAth).Merge(overlay)
+	u.Equal(nil, sm.Error)
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.NotContains(keys, "server.timeout.read")
+	for _, key := range keys {
+		u.NotContains(key, "email.")
+		u.NotContains(key, deleteMarkerKey)
+	}
+
+	i, err := sm.GetInt("server.timeout.write")
+	u.Equal(nil, err)
+	u.Equal(10, i)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	sm.Reload()
+	_, err = sm.GetString("email.server.address")
+	u.Equal("settings.GetString :: email.server.address :: cannot find value in configuration", fmt.Sprint(err))
+
+	resetTest()
+}
+
+func (u unitDeletionsSuite) TestDeleteMarker() {
+	sm := NewFromContent(testYamlContent).
+		Set("service.name", "Override").
+		MergeContent(`{"service": {"$delete": true, "id": "S-1"}, "server": {"$delete": false}}`, "json")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Override", v)
+
+	v, err = sm.GetString("service.id")
+	u.Equal(nil, err)
+	u.Equal("S-1", v)
+
+	i, err := sm.GetInt("server.timeout.read")
+	u.Equal(nil, err)
+	u.Equal(5, i)
+
+	sm = NewFromContent(testYamlContent).MergeContent(`{"$delete": true, "a": 1}`, "json")
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.Equal([]string{"a"}, keys)
+
+	sm = NewFromContent(testYamlContent).MergeContent(`{"service": {"$delete": "yes"}}`, "json")
+	u.Equal("settings.MergeContent :: service.$delete :: should be a boolean", fmt.Sprint(sm.Error))
+}
+
+func (u unitDeletionsSuite) TestResolveUnsetTags() {
+	content := []byte("a: 1\n")
+	b, err := resolveUnsetTags(content)
+	u.Equal(nil, err)
+	u.Equal(content, b)
+
+	content = []byte("a: '!unset'\n")
+	b, err = resolveUnsetTags(content)
+	u.Equal(nil, err)
+	u.Equal(content, b)
+
+	docs, err := decodeYAMLDocuments([]byte("a: !unset\n---\nb:\n  c: !unset\n"))
+	u.Equal(nil, err)
+	u.Equal(2, len(docs))
+	u.Equal(map[interface{}]interface{}{deleteMarkerKey: true}, docs[0]["a"])
+
+	_, err = resolveUnsetTags([]byte("a: !unset\nb: [\n"))
+	u.NotEqual(nil, err)
+}
+
+func TestDeletionsUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitDeletionsSuite))
+}
@@ -0,0 +1,227 @@
+package settings
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// SubdirectoryOrder decides, how the files of the subdirectories rank against the files of their parent directory.
+type SubdirectoryOrder int
+
+const (
+	// SubdirectoriesInline merges the files of a subdirectory at the place of the subdirectory
+	// among the entries of its parent directory, like filepath.Walk visits them. It is the default order.
+	SubdirectoriesInline SubdirectoryOrder = iota
+
+	// SubdirectoriesLast merges the files of the subdirectories after the files of their parent directory,
+	// so that the files of the subdirectories take precedence.
+	SubdirectoriesLast
+
+	// SubdirectoriesFirst merges the files of the subdirectories before the files of their parent directory,
+	// so that the files of the parent directory take precedence.
+	SubdirectoriesFirst
+)
+
+// DirectoryOptions configures, which files of a directory are merged and in which order.
+//
+// The entries of a directory are merged in lexical order of their names, the files merged later
+// take precedence. Hidden files and directories (".name") and editor backups ("name~", ".swp", ".swo")
+// are always skipped.
+type DirectoryOptions struct {
+	// NonRecursive merges only the files directly under the directory.
+	NonRecursive bool
+
+	// Include are glob patterns, e.g. "*.yaml" or "prod/*.json", at least one of them has to match a file.
+	// A pattern without a "/" is matched against the name of a file,
+	// otherwise against its path relative to the directory. All files match an empty list.
+	Include []string
+
+	// Exclude are glob patterns matched like Include, the matching files and directories are skipped.
+	Exclude []string
+
+	// NumericOrder orders the names starting with a number by that number, e.g. "2-a.yaml" before "10-b.yaml".
+	// The names starting with a number come before the other ones.
+	NumericOrder bool
+
+	// Subdirectories decides, how the files of the subdirectories rank against the files of their parent.
+	Subdirectories SubdirectoryOrder
+}
+
+// NewFromDirectory initializes settings from the files under a directory, merged by the given options.
+func NewFromDirectory(dir string, opts DirectoryOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.MergeDirectory(dir, opts)
+}
+
+// MergeDirectory merges initialized settings with the files under a directory, merged by the given options.
+// The files are reported by GetSettingsFileNames and watched by AutoReload like the files added by Merge.
+func (s *Settings) MergeDirectory(dir string, opts DirectoryOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	if err := opts.validate(); err != nil {
+		return &Settings{Error: fmt.Errorf("settings.MergeDirectory :: %s", err)}
+	}
+	if !isDirectory(dir) {
+		return &Settings{Error: fmt.Errorf("settings.MergeDirectory :: %s :: not a directory", dir)}
+	}
+	if err := s.addSource(&fileSource{file: dir, opts: opts}, 0); err != nil {
+		return &Settings{Error: fmt.Errorf("settings.MergeDirectory :: %s", err)}
+	}
+	return s
+}
+
+func (o DirectoryOptions) validate() error {
+	for _, pattern := range append(o.Include[:len(o.Include):len(o.Include)], o.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s :: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+// listSettingsFiles returns the settings files under a directory of a file system in the order they are merged.
+func listSettingsFiles(fsys fs.FS, dir string, opts DirectoryOptions) ([]string, error) {
+	return listSettingsFilesUnder(fsys, dir, "", opts)
+}
+
+func listSettingsFilesUnder(fsys fs.FS, root, rel string, opts DirectoryOptions) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, path.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return opts.less(entries[i].Name(), entries[j].Name())
+	})
+
+	var files, subdirectories []string
+	for _, e := range entries {
+		name := path.Join(rel, e.Name())
+		if isIgnoredFile(e.Name()) || matchAny(opts.Exclude, name) {
+			continue
+		}
+
+		if e.IsDir() {
+			if opts.NonRecursive {
+				continue
+			}
+			sub, err := listSettingsFilesUnder(fsys, root, name, opts)
+			if err != nil {
+				return nil, err
+			}
+			if opts.Subdirectories == SubdirectoriesInline {
+				files = append(files, sub...)
+			} else {
+				subdirectories = append(subdirectories, sub...)
+			}
+			continue
+		}
+
+		if isSettingsFile(name) && (len(opts.Include) == 0 || matchAny(opts.Include, name)) {
+			files = append(files, name)
+		}
+	}
+
+	if opts.Subdirectories == SubdirectoriesFirst {
+		return append(subdirectories, files...), nil
+	}
+	return append(files, subdirectories...), nil
+}
+
+// listFilesUnderDirectory returns the settings files under a directory in the order they are merged.
+func listFilesUnderDirectory(dir string, opts DirectoryOptions) ([]string, error) {
+	files, err := listSettingsFiles(os.DirFS(dir), ".", opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		files[i] = filepath.Join(dir, filepath.FromSlash(file))
+	}
+	return files, nil
+}
+
+// listFilesUnderDirectoryFS returns the settings files under a directory of a file system in the order they are merged.
+func listFilesUnderDirectoryFS(fsys fs.FS, dir string) ([]string, error) {
+	files, err := listSettingsFiles(fsys, dir, DirectoryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		files[i] = path.Join(dir, file)
+	}
+	return files, nil
+}
+
+// less orders the names of the entries of a directory.
+func (o DirectoryOptions) less(a, b string) bool {
+	if o.NumericOrder {
+		na, nb := numericPrefix(a), numericPrefix(b)
+		switch {
+		case na != "" && nb == "":
+			return true
+		case na == "" && nb != "":
+			return false
+		case na != nb:
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			return na < nb
+		}
+	}
+	return a < b
+}
+
+// numericPrefix returns the leading digits of a name without the leading zeros, e.g. "10" of "010-app.yaml".
+func numericPrefix(name string) string {
+	i := 0
+	for i < len(name) && name[i] >= '0' && name[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return ""
+	}
+	if n := strings.TrimLeft(name[:i], "0"); n != "" {
+		return n
+	}
+	return "0"
+}
+
+// isIgnoredFile reports whether a file or directory is hidden, or it is a backup of an editor.
+func isIgnoredFile(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") ||
+		strings.HasSuffix(name, ".swo")
+}
+
+// isIgnoredPath reports whether any element of a slash separated path is ignored, see isIgnoredFile.
+func isIgnoredPath(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if elem != "." && elem != ".." && isIgnoredFile(elem) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny reports whether a slash separated path relative to a directory matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
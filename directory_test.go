@@ -0,0 +1,125 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitDirectorySuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitDirectorySuite) TestMergeDirectory() {
+	initTestDirectory(u)
+
+	for _, tc := range []struct {
+		opts     DirectoryOptions
+		expected []string
+	}{
+		{DirectoryOptions{}, []string{"10-b.yaml", "2-a.yaml", "a/c.yaml", "a/z.yaml", "b.json", "z.yaml"}},
+		{DirectoryOptions{NonRecursive: true}, []string{"10-b.yaml", "2-a.yaml", "b.json", "z.yaml"}},
+		{DirectoryOptions{NumericOrder: true}, []string{"2-a.yaml", "10-b.yaml", "a/c.yaml", "a/z.yaml", "b.json", "z.yaml"}},
+		{DirectoryOptions{Subdirectories: SubdirectoriesLast}, []string{"10-b.yaml", "2-a.yaml", "b.json", "z.yaml", "a/c.yaml", "a/z.yaml"}},
+		{DirectoryOptions{Subdirectories: SubdirectoriesFirst}, []string{"a/c.yaml", "a/z.yaml", "10-b.yaml", "2-a.yaml", "b.json", "z.yaml"}},
+		{DirectoryOptions{Include: []string{"*.yaml"}, Exclude: []string{"z.*"}}, []string{"10-b.yaml", "2-a.yaml", "a/c.yaml"}},
+		{DirectoryOptions{Include: []string{"a/*"}}, []string{"a/c.yaml", "a/z.yaml"}},
+		{DirectoryOptions{Exclude: []string{"a"}}, []string{"10-b.yaml", "2-a.yaml", "b.json", "z.yaml"}},
+	} {
+		sm := NewFromDirectory(testDirectoryPath, tc.opts)
+		u.Equal(nil, sm.Error)
+
+		files, err := sm.GetSettingsFileNames()
+		u.Equal(nil, err)
+
+		var expected []string
+		for _, file := range tc.expected {
+			expected = append(expected, filepath.Join(testDirectoryPath, file))
+		}
+		u.Equal(expected, files, fmt.Sprintf("%+v", tc.opts))
+
+		last, err := sm.GetString("last")
+		u.Equal(nil, err)
+		u.Equal(tc.expected[len(tc.expected)-1], last)
+	}
+
+	resetTest()
+}
+
+func (u unitDirectorySuite) TestMergeDirectoryErrors() {
+	initTestDirectory(u)
+
+	sm := New(testYamlFilePAth).MergeDirectory(testDirectoryPath, DirectoryOptions{Include: []string{"[a"}})
+	u.Equal("settings.MergeDirectory :: [a :: syntax error in pattern", fmt.Sprint(sm.Error))
+
+	sm = NewFromDirectory(testYamlFilePAth, DirectoryOptions{})
+	u.Equal("settings.MergeDirectory :: ./settings/test.yaml :: not a directory", fmt.Sprint(sm.Error))
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("error", fmt.Sprint(sm.MergeDirectory(testDirectoryPath, DirectoryOptions{}).Error))
+
+	resetTest()
+}
+
+func (u unitDirectorySuite) TestListFilesUnderDirectoryFS() {
+	fsys := fstest.MapFS{
+		"conf/b.yaml":         {Data: []byte("last: b.yaml")},
+		"conf/a/c.yaml":       {Data: []byte("last: a/c.yaml")},
+		"conf/.hidden.yaml":   {Data: []byte("last: hidden")},
+		"conf/..data/x.yaml":  {Data: []byte("last: data")},
+		"conf/b.yaml.swp":     {Data: []byte("last: swap")},
+		"conf/notes.txt":      {Data: []byte("notes")},
+		"conf/a/backup.yaml~": {Data: []byte("last: backup")},
+	}
+
+	files, err := listFilesUnderDirectoryFS(fsys, "conf")
+	u.Equal(nil, err)
+	u.Equal([]string{"conf/a/c.yaml", "conf/b.yaml"}, files)
+
+	_, err = listFilesUnderDirectoryFS(fsys, "missing")
+	u.NotEqual(nil, err)
+}
+
+func (u unitDirectorySuite) TestNumericPrefix() {
+	u.Equal("10", numericPrefix("010-app.yaml"))
+	u.Equal("0", numericPrefix("00-app.yaml"))
+	u.Equal("", numericPrefix("app.yaml"))
+
+	opts := DirectoryOptions{NumericOrder: true}
+	u.Equal(true, opts.less("9-a.yaml", "10-a.yaml"))
+	u.Equal(true, opts.less("10-a.yaml", "10-b.yaml"))
+	u.Equal(true, opts.less("99999999999999999999-a.yaml", "100000000000000000000-a.yaml"))
+	u.Equal(true, opts.less("1-a.yaml", "a.yaml"))
+	u.Equal(false, DirectoryOptions{}.less("9-a.yaml", "10-a.yaml"))
+}
+
+func TestDirectoryUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitDirectorySuite))
+}
+
+func initTestDirectory(u unitDirectorySuite) {
+	initTestOk()
+
+	for _, file := range []string{"z.yaml", "2-a.yaml", "10-b.yaml", "b.json", "a/c.yaml", "a/z.yaml", ".hidden.yaml", "a/.z.yaml", "z.yaml~", ".z.yaml.swp"} {
+		name := filepath.Join(testDirectoryPath, file)
+		u.Equal(nil, os.MkdirAll(filepath.Dir(name), os.ModePerm))
+
+		content := fmt.Sprintf("last: %s\n", file)
+		if filepath.Ext(file) == ".json" {
+			content = fmt.Sprintf(`{"last": %q}`, file)
+		}
+		u.Equal(nil, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+}
+
+var (
+	testDirectoryPath = "./settings/conf.d"
+)
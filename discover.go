@@ -0,0 +1,179 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DiscoverOptions configures, where Discover looks for the settings files of an application.
+type DiscoverOptions struct {
+	// SearchPaths are the directories searched for File, the most important one first.
+	// A leading "~" and the environment variables, e.g. "$XDG_CONFIG_HOME", are expanded,
+	// a path referring to an unset variable is skipped. The default is DefaultSearchPaths.
+	SearchPaths []string
+
+	// File is the name of the settings file in the search paths, the default is "config".
+	// A name without a known extension matches the files of any registered format, e.g. "config.yaml".
+	File string
+
+	// Upward is the name of the settings file searched in the start directory and in its parent directories
+	// up to the root, e.g. ".apprc.yaml". The default is "." + name + "rc" with any extension of a registered format.
+	Upward string
+
+	// NoUpward disables the upward search.
+	NoUpward bool
+
+	// StartDir is the directory, where the upward search starts, the default is the working directory.
+	StartDir string
+
+	// First merges only the most important file found, instead of merging all of them.
+	First bool
+}
+
+// DefaultSearchPaths returns the directories searched for the settings of an application by default,
+// the most important one first: "$XDG_CONFIG_HOME/<name>", "~/.config/<name>" and "/etc/<name>".
+func DefaultSearchPaths(name string) []string {
+	return []string{
+		filepath.Join("$XDG_CONFIG_HOME", name),
+		filepath.Join("~", ".config", name),
+		filepath.Join(string(filepath.Separator), "etc", name),
+	}
+}
+
+// Discover initializes settings from the settings files of an application found by DiscoverFiles.
+//
+// All files found are merged, the more important ones take precedence: the file nearest to the start directory
+// of the upward search first, then the files of the search paths in the given order.
+// When First is set, only the most important file is merged. The merged files are reported by GetSettingsFileNames.
+func Discover(name string, opts DiscoverOptions) *Settings {
+	files, err := DiscoverFiles(name, opts)
+	if err != nil {
+		return &Settings{Error: fmt.Errorf("settings.Discover :: %s", err)}
+	}
+	if len(files) == 0 {
+		return &Settings{Error: fmt.Errorf("settings.Discover :: %s :: cannot find settings file", name)}
+	}
+	if opts.First {
+		files = files[:1]
+	}
+
+	s := &Settings{}
+	s.Data = viper.New()
+	for i := len(files) - 1; i >= 0; i-- {
+		if s = s.Merge(files[i]); s.Error != nil {
+			return &Settings{Error: fmt.Errorf("settings.Discover :: %s", s.Error)}
+		}
+	}
+	return s
+}
+
+// DiscoverFiles returns the settings files of an application found by the given options, the most important one first.
+func DiscoverFiles(name string, opts DiscoverOptions) ([]string, error) {
+	var files []string
+
+	if !opts.NoUpward {
+		upward := opts.Upward
+		if upward == "" {
+			upward = "." + name + "rc"
+		}
+		found, err := discoverUpward(opts.StartDir, upward)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+
+	paths := opts.SearchPaths
+	if paths == nil {
+		paths = DefaultSearchPaths(name)
+	}
+	file := opts.File
+	if file == "" {
+		file = "config"
+	}
+	for _, p := range paths {
+		dir, ok := expandPath(p)
+		if !ok {
+			continue
+		}
+		files = append(files, findSettingsFiles(dir, file)...)
+	}
+	return makeUniqueSlice(files), nil
+}
+
+// discoverUpward returns the settings files of a name found in a directory and in its parent directories,
+// the nearest one first.
+func discoverUpward(start, name string) ([]string, error) {
+	if start == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		start = wd
+	}
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for {
+		files = append(files, findSettingsFiles(dir, name)...)
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return files, nil
+		}
+		dir = parent
+	}
+}
+
+// findSettingsFiles returns the existing settings files of a name in a directory.
+// A name without a known extension is tried with the extensions of all registered formats.
+func findSettingsFiles(dir, name string) []string {
+	candidates := []string{name}
+	if !isSettingsFile(name) {
+		candidates = nil
+		for _, ext := range formats.extensions() {
+			candidates = append(candidates, name+ext)
+		}
+	}
+
+	var files []string
+	for _, c := range candidates {
+		file := filepath.Join(dir, c)
+		if fi, err := os.Stat(file); err == nil && !fi.IsDir() {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// expandPath expands a leading "~" and the environment variables of a path.
+// It reports false, when the path refers to an unset environment variable.
+func expandPath(p string) (string, bool) {
+	ok := true
+	p = os.Expand(p, func(name string) string {
+		v := os.Getenv(name)
+		if v == "" {
+			ok = false
+		}
+		return v
+	})
+	if !ok {
+		return "", false
+	}
+
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	return p, true
+}
@@ -0,0 +1,164 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitDiscoverSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitDiscoverSuite) TestDiscover() {
+	root := initTestDiscover(u)
+	defer restoreTestEnv("HOME", "XDG_CONFIG_HOME")()
+	u.Equal(nil, os.Setenv("HOME", filepath.Join(root, "home")))
+	u.Equal(nil, os.Unsetenv("XDG_CONFIG_HOME"))
+
+	opts := DiscoverOptions{
+		StartDir:    filepath.Join(root, "project", "sub"),
+		SearchPaths: append(DefaultSearchPaths("app")[:2], filepath.Join(root, "etc", "app")),
+	}
+
+	files, err := DiscoverFiles("app", opts)
+	u.Equal(nil, err)
+	u.Equal([]string{
+		filepath.Join(root, "project", "sub", ".apprc.json"),
+		filepath.Join(root, "project", ".apprc.yaml"),
+		filepath.Join(root, "home", ".config", "app", "config.yaml"),
+		filepath.Join(root, "etc", "app", "config.json"),
+	}, files)
+
+	sm := Discover("app", opts)
+	u.Equal(nil, sm.Error)
+
+	names, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{files[3], files[2], files[1], files[0]}, names)
+
+	for key, expected := range map[string]string{
+		"from.etc":     "etc",
+		"from.home":    "home",
+		"from.project": "project",
+		"from.nearest": "sub",
+		"level":        "sub",
+	} {
+		v, err := sm.GetString(key)
+		u.Equal(nil, err)
+		u.Equal(expected, v, key)
+	}
+
+	u.Equal(nil, os.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg")))
+	opts.First, opts.NoUpward = true, true
+
+	sm = Discover("app", opts)
+	u.Equal(nil, sm.Error)
+
+	names, err = sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{filepath.Join(root, "xdg", "app", "config.yml")}, names)
+
+	resetTest()
+}
+
+func (u unitDiscoverSuite) TestDiscoverOptions() {
+	root := initTestDiscover(u)
+
+	files, err := DiscoverFiles("app", DiscoverOptions{
+		StartDir:    filepath.Join(root, "project", "sub"),
+		Upward:      ".apprc.yaml",
+		SearchPaths: []string{filepath.Join(root, "etc", "app")},
+		File:        "other.yaml",
+	})
+	u.Equal(nil, err)
+	u.Equal([]string{
+		filepath.Join(root, "project", ".apprc.yaml"),
+		filepath.Join(root, "etc", "app", "other.yaml"),
+	}, files)
+
+	sm := Discover("missing", DiscoverOptions{NoUpward: true, SearchPaths: []string{}})
+	u.Equal("settings.Discover :: missing :: cannot find settings file", fmt.Sprint(sm.Error))
+
+	u.Equal(nil, ioutil.WriteFile(filepath.Join(root, "etc", "app", "config.yaml"), []byte("a: [\n"), 0644))
+	sm = Discover("app", DiscoverOptions{NoUpward: true, SearchPaths: []string{filepath.Join(root, "etc", "app")}})
+	u.Contains(fmt.Sprint(sm.Error), "settings.Discover :: While parsing config:")
+
+	resetTest()
+}
+
+func (u unitDiscoverSuite) TestExpandPath() {
+	defer restoreTestEnv("HOME", "SETTINGS_TEST_DIR")()
+	u.Equal(nil, os.Setenv("HOME", "/home/test"))
+	u.Equal(nil, os.Setenv("SETTINGS_TEST_DIR", "/srv"))
+	u.Equal(nil, os.Unsetenv("SETTINGS_TEST_MISSING"))
+
+	p, ok := expandPath("~/.config/app")
+	u.Equal(true, ok)
+	u.Equal("/home/test/.config/app", p)
+
+	p, ok = expandPath("${SETTINGS_TEST_DIR}/app")
+	u.Equal(true, ok)
+	u.Equal("/srv/app", p)
+
+	p, ok = expandPath("/etc/~app")
+	u.Equal(true, ok)
+	u.Equal("/etc/~app", p)
+
+	_, ok = expandPath("$SETTINGS_TEST_MISSING/app")
+	u.Equal(false, ok)
+}
+
+func TestDiscoverUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitDiscoverSuite))
+}
+
+func initTestDiscover(u unitDiscoverSuite) string {
+	initTestOk()
+
+	root, err := filepath.Abs(filepath.Join(testDirPath, "discover"))
+	u.Equal(nil, err)
+
+	for file, content := range map[string]string{
+		"etc/app/config.json":            `{"from": {"etc": "etc"}, "level": "etc"}`,
+		"etc/app/other.yaml":             "level: other\n",
+		"home/.config/app/config.yaml":   "from:\n  home: home\nlevel: home\n",
+		"xdg/app/config.yml":             "from:\n  xdg: xdg\nlevel: xdg\n",
+		"project/.apprc.yaml":            "from:\n  project: project\nlevel: project\n",
+		"project/sub/.apprc.json":        `{"from": {"nearest": "sub"}, "level": "sub"}`,
+		"project/sub/.apprc.yaml.swp":    "level: swap\n",
+		"project/sub/nested/.apprc.yaml": "level: nested\n",
+	} {
+		name := filepath.Join(root, filepath.FromSlash(file))
+		u.Equal(nil, os.MkdirAll(filepath.Dir(name), os.ModePerm))
+		u.Equal(nil, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+	return root
+}
+
+// restoreTestEnv returns a function, which restores the environment variables to their current values.
+func restoreTestEnv(names ...string) func() {
+	values := map[string]*string{}
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			values[name] = &v
+		} else {
+			values[name] = nil
+		}
+	}
+	return func() {
+		for name, v := range values {
+			if v == nil {
+				_ = os.Unsetenv(name)
+			} else {
+				_ = os.Setenv(name, *v)
+			}
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package settings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultEtcdEndpoint   = "http://127.0.0.1:2379"
+	defaultEtcdRetryDelay = time.Second
+)
+
+// EtcdOptions configures an etcd v3 settings source, which uses the JSON gateway of etcd.
+type EtcdOptions struct {
+	// Endpoint of the etcd JSON gateway, defaults to http://127.0.0.1:2379.
+	Endpoint string
+
+	// Header is sent with every request, e.g. an Authorization token.
+	Header http.Header
+
+	// ContentType is the type of the value, e.g. "json" or "yaml", when the key holds a single blob.
+	// If empty, the keys under the prefix are mapped to nested settings, e.g. "/app/db/host" becomes "db.host".
+	ContentType string
+
+	// RetryDelay is the delay before reconnecting a broken watch, defaults to 1 second.
+	RetryDelay time.Duration
+
+	// Client is used to send the requests, defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Cache stores the last successful payload, which is loaded when the source cannot be reached.
+	Cache CacheOptions
+}
+
+type etcdKeyValue struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader     `json:"header"`
+	Kvs    []etcdKeyValue `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader `json:"header"`
+		Created         bool       `json:"created"`
+		Canceled        bool       `json:"canceled"`
+		CompactRevision int64      `json:"compact_revision,string"`
+		Events          []struct {
+			Type string       `json:"type"`
+			Kv   etcdKeyValue `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type etcdSource struct {
+	prefix string
+	opts   EtcdOptions
+
+	mux      sync.Mutex
+	revision int64
+	kvs      map[string][]byte
+	watching bool
+}
+
+// NewFromEtcd initializes settings from a key or a key prefix of etcd v3.
+func NewFromEtcd(prefix string, opts EtcdOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeRemote(withCache(newEtcdSource(prefix, opts), opts.Cache), "NewFromEtcd")
+}
+
+// MergeEtcd merges initialized settings with a key or a key prefix of etcd v3.
+// AutoReload subscribes to the watch events of the prefix and applies them incrementally.
+// After a compaction or a disconnect the prefix is read again fully.
+// Connection errors are sent to the channel returned by AutoReloadErrors.
+func (s *Settings) MergeEtcd(prefix string, opts EtcdOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeRemote(withCache(newEtcdSource(prefix, opts), opts.Cache), "MergeEtcd")
+}
+
+func newEtcdSource(prefix string, opts EtcdOptions) *etcdSource {
+	if opts.Endpoint == "" {
+		opts.Endpoint = defaultEtcdEndpoint
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultEtcdRetryDelay
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &etcdSource{prefix: prefix, opts: opts}
+}
+
+func (e *etcdSource) name() string {
+	return "etcd:" + e.prefix
+}
+
+// load reads the prefix, unless it is kept up to date by a running watch.
+func (e *etcdSource) load() (map[string]interface{}, error) {
+	if !e.watched() {
+		if _, err := e.resync(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.decode()
+}
+
+func (e *etcdSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	e.mux.Lock()
+	e.watching = true
+	e.mux.Unlock()
+
+	defer func() {
+		e.mux.Lock()
+		e.watching = false
+		e.mux.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		err := e.stream(ctx, reload)
+		if ctx.Err() != nil {
+			return
+		}
+		report(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.opts.RetryDelay):
+		}
+
+		changed, err := e.resync(ctx)
+		if err != nil {
+			report(err)
+		} else if changed {
+			reload()
+		}
+	}
+}
+
+// key returns the key read by the source. A tree is read under the prefix followed by "/",
+// so that the keys of a sibling, e.g. "/apple/..." of "/app", are not included.
+func (e *etcdSource) key() string {
+	if e.opts.ContentType == "" && e.prefix != "" && !strings.HasSuffix(e.prefix, "/") {
+		return e.prefix + "/"
+	}
+	return e.prefix
+}
+
+// watched reports whether the keys are kept up to date by a running watch.
+// The keys are never read yet, when the settings were loaded from the offline cache.
+func (e *etcdSource) watched() bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.watching && e.kvs != nil
+}
+
+// synced reports whether the keys of the prefix were read.
+func (e *etcdSource) synced() bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.kvs != nil
+}
+
+// resync reads all keys of the prefix, and reports whether they have changed.
+func (e *etcdSource) resync(ctx context.Context) (bool, error) {
+	var resp etcdRangeResponse
+	body := map[string]interface{}{"key": []byte(e.key())}
+	if e.opts.ContentType == "" {
+		body["range_end"] = etcdPrefixEnd(e.key())
+	}
+	if err := e.post(ctx, "/v3/kv/range", body, &resp); err != nil {
+		return false, err
+	}
+
+	kvs := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs[string(kv.Key)] = kv.Value
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	changed := !equalKeyValues(e.kvs, kvs)
+	e.kvs = kvs
+	e.revision = resp.Header.Revision
+	return changed, nil
+}
+
+// stream applies the watch events to the keys until the stream breaks.
+// The keys are read first, when they were not read yet, e.g. the settings were loaded from the offline cache.
+func (e *etcdSource) stream(ctx context.Context, reload func()) error {
+	if !e.synced() {
+		if _, err := e.resync(ctx); err != nil {
+			return err
+		}
+		reload()
+	}
+
+	e.mux.Lock()
+	create := map[string]interface{}{
+		"key":            []byte(e.key()),
+		"start_revision": fmt.Sprint(e.revision + 1),
+	}
+	e.mux.Unlock()
+	if e.opts.ContentType == "" {
+		create["range_end"] = etcdPrefixEnd(e.key())
+	}
+
+	resp, err := e.request(ctx, "/v3/watch", map[string]interface{}{"create_request": create})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var w etcdWatchResponse
+		if err := dec.Decode(&w); err != nil {
+			return fmt.Errorf("etcd: %s :: watch: %s", e.prefix, err)
+		}
+		if w.Error != nil {
+			return fmt.Errorf("etcd: %s :: watch: %s", e.prefix, w.Error.Message)
+		}
+		if w.Result.CompactRevision > 0 || w.Result.Canceled {
+			return fmt.Errorf("etcd: %s :: watch canceled at compacted revision: %d", e.prefix, w.Result.CompactRevision)
+		}
+		if len(w.Result.Events) == 0 {
+			continue
+		}
+
+		e.applyEvents(w)
+		reload()
+	}
+}
+
+// applyEvents applies the events of a watch response to the keys.
+func (e *etcdSource) applyEvents(w etcdWatchResponse) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.kvs == nil {
+		e.kvs = map[string][]byte{}
+	}
+	for _, event := range w.Result.Events {
+		if event.Type == "DELETE" {
+			delete(e.kvs, string(event.Kv.Key))
+		} else {
+			e.kvs[string(event.Kv.Key)] = event.Kv.Value
+		}
+	}
+	e.revision = w.Result.Header.Revision
+}
+
+func (e *etcdSource) post(ctx context.Context, path string, body, result interface{}) error {
+	resp, err := e.request(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("etcd: %s :: %s", e.prefix, err)
+	}
+	return nil
+}
+
+func (e *etcdSource) request(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(e.opts.Endpoint, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range e.opts.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := e.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("etcd: %s :: unexpected status: %s", e.prefix, resp.Status)
+	}
+	return resp, nil
+}
+
+func (e *etcdSource) decode() (map[string]interface{}, error) {
+	if e.opts.ContentType != "" {
+		f, ok := formats.byName(e.opts.ContentType)
+		if !ok {
+			return nil, fmt.Errorf("etcd: %s :: unsupported content type: %s", e.prefix, e.opts.ContentType)
+		}
+		value, ok := e.kvs[e.prefix]
+		if !ok {
+			return nil, fmt.Errorf("etcd: %s :: cannot find key", e.prefix)
+		}
+		return f.decode(value)
+	}
+
+	keys := make([]string, 0, len(e.kvs))
+	for key := range e.kvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	m := map[string]interface{}{}
+	for _, key := range keys {
+		k := strings.Trim(strings.TrimPrefix(key, e.prefix), "/")
+		if k == "" {
+			continue
+		}
+		setNested(m, strings.Split(k, "/"), inferScalar(string(e.kvs[key])))
+	}
+	return m, nil
+}
+
+// etcdPrefixEnd returns the range end, which covers all keys with the given prefix.
+func etcdPrefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
+
+func equalKeyValues(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
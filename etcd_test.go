@@ -0,0 +1,397 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitEtcdSuite struct {
+		suite.Suite
+	}
+
+	testEtcdServer struct {
+		mux       sync.Mutex
+		kv        map[string]string
+		revision  int64
+		compacted int64
+		watchers  map[chan map[string]interface{}]bool
+		ranges    int
+	}
+)
+
+func (u unitEtcdSuite) TestNewFromEtcd() {
+	ts := newTestEtcdServer(map[string]string{
+		"/app/db/host": "db.example.com",
+		"/app/db/port": "5432",
+		"/app/debug":   "false",
+		"/apple/color": "red",
+		"/other/key":   "ignored",
+	})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := NewFromEtcd("/app/", EtcdOptions{Endpoint: server.URL})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("db.host")
+	u.Equal(nil, err)
+	u.Equal("db.example.com", v)
+
+	i, err := sm.GetInt("db.port")
+	u.Equal(nil, err)
+	u.Equal(5432, i)
+
+	b, err := sm.GetBool("debug")
+	u.Equal(nil, err)
+	u.Equal(false, b)
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	sort.Strings(keys)
+	u.Equal([]string{"db.host", "db.port", "debug"}, keys)
+
+	sm = NewFromEtcd("/app", EtcdOptions{Endpoint: server.URL})
+	u.Equal(nil, sm.Error)
+
+	keys, err = sm.GetAllKeys()
+	u.Equal(nil, err)
+	sort.Strings(keys)
+	u.Equal([]string{"db.host", "db.port", "debug"}, keys)
+}
+
+func (u unitEtcdSuite) TestMergeEtcdBlob() {
+	initTestOk()
+
+	ts := newTestEtcdServer(map[string]string{
+		"/config/app.json": `{"service": {"name": "FromEtcd"}}`,
+	})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := New(testYamlFilePAth).MergeEtcd("/config/app.json", EtcdOptions{Endpoint: server.URL, ContentType: "json"})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("FromEtcd", v)
+
+	sm = NewFromEtcd("/config/missing.json", EtcdOptions{Endpoint: server.URL, ContentType: "json"})
+	u.Equal("settings.NewFromEtcd :: etcd: /config/missing.json :: cannot find key", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitEtcdSuite) TestEtcdWatch() {
+	ts := newTestEtcdServer(map[string]string{"/app/level": "info", "/app/old": "value"})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	reloaded := make(chan struct{}, 10)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := NewFromEtcd("/app/", EtcdOptions{Endpoint: server.URL, RetryDelay: 50 * time.Millisecond})
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+	ts.waitForWatchers(1)
+
+	ts.put("/app/level", "debug")
+	u.waitForReload(reloaded)
+
+	v, err := sm.Get("level")
+	u.Equal(nil, err)
+	u.Equal("debug", v)
+
+	ts.delete("/app/old")
+	u.waitForReload(reloaded)
+
+	isSet, err := sm.IsSet("old")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+	u.Equal(1, ts.rangeCount())
+
+	ts.disconnect()
+	ts.put("/app/level", "warn")
+
+	select {
+	case err := <-sm.AutoReloadErrors():
+		u.Contains(fmt.Sprint(err), "etcd: /app/ :: watch:")
+	case <-time.After(5 * time.Second):
+		u.Fail("connection error was not reported")
+	}
+	u.waitForReload(reloaded)
+	sm.StopAutoReload()
+
+	v, err = sm.Get("level")
+	u.Equal(nil, err)
+	u.Equal("warn", v)
+	u.Equal(2, ts.rangeCount())
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+}
+
+func (u unitEtcdSuite) TestEtcdWatchAfterOfflineStart() {
+	initTestOk()
+
+	ts := newTestEtcdServer(map[string]string{"/app/level": "info"})
+	var down int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		ts.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	opts := EtcdOptions{Endpoint: server.URL, RetryDelay: 10 * time.Millisecond, Cache: CacheOptions{File: testCacheFilePath}}
+	u.Equal(nil, NewFromEtcd("/app/", opts).Error)
+
+	reloaded := make(chan struct{}, 10)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	atomic.StoreInt32(&down, 1)
+	sm := NewFromEtcd("/app/", opts)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("level")
+	u.Equal(nil, err)
+	u.Equal("info", v)
+
+	atomic.StoreInt32(&down, 0)
+	sm.AutoReload()
+	ts.waitForWatchers(1)
+	u.waitForReload(reloaded)
+
+	ts.put("/app/level", "debug")
+	u.waitForReload(reloaded)
+	sm.StopAutoReload()
+
+	v, err = sm.Get("level")
+	u.Equal(nil, err)
+	u.Equal("debug", v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+	resetTest()
+}
+
+func (u unitEtcdSuite) TestEtcdCompaction() {
+	ts := newTestEtcdServer(map[string]string{"/app/level": "info"})
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	e := newEtcdSource("/app/", EtcdOptions{Endpoint: server.URL})
+	_, err := e.load()
+	u.Equal(nil, err)
+
+	ts.put("/app/level", "debug")
+	ts.compact()
+
+	err = e.stream(context.Background(), func() {})
+	u.Equal("etcd: /app/ :: watch canceled at compacted revision: 2", fmt.Sprint(err))
+}
+
+func (u unitEtcdSuite) TestEtcdDecodeOrder() {
+	e := newEtcdSource("/app/", EtcdOptions{})
+	e.kvs = map[string][]byte{
+		"/app/a":   []byte("scalar"),
+		"/app/a/b": []byte("nested"),
+		"/app/c":   []byte("1"),
+		"/app/d/e": []byte("true"),
+	}
+
+	for i := 0; i < 100; i++ {
+		m, err := e.decode()
+		u.Equal(nil, err)
+		u.Equal(map[string]interface{}{
+			"a": map[string]interface{}{"b": "nested"},
+			"c": 1,
+			"d": map[string]interface{}{"e": true},
+		}, m)
+	}
+}
+
+func (u unitEtcdSuite) TestEtcdPrefixEnd() {
+	u.Equal([]byte("/app0"), etcdPrefixEnd("/app/"))
+	u.Equal([]byte{'a', 0x01}, etcdPrefixEnd(string([]byte{'a', 0x00})))
+	u.Equal([]byte{'b'}, etcdPrefixEnd(string([]byte{'a', 0xff})))
+	u.Equal([]byte{0}, etcdPrefixEnd(string([]byte{0xff})))
+}
+
+func (u unitEtcdSuite) waitForReload(reloaded chan struct{}) {
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+}
+
+func TestEtcdUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitEtcdSuite))
+}
+
+func newTestEtcdServer(kv map[string]string) *testEtcdServer {
+	return &testEtcdServer{kv: kv, revision: 1, watchers: map[chan map[string]interface{}]bool{}}
+}
+
+func (ts *testEtcdServer) put(key, value string) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	ts.revision++
+	ts.kv[key] = value
+	ts.notify(map[string]interface{}{"type": "PUT", "kv": map[string]interface{}{"key": []byte(key), "value": []byte(value)}})
+}
+
+func (ts *testEtcdServer) delete(key string) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	ts.revision++
+	delete(ts.kv, key)
+	ts.notify(map[string]interface{}{"type": "DELETE", "kv": map[string]interface{}{"key": []byte(key)}})
+}
+
+func (ts *testEtcdServer) compact() {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	ts.compacted = ts.revision
+}
+
+func (ts *testEtcdServer) disconnect() {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	for w := range ts.watchers {
+		close(w)
+		delete(ts.watchers, w)
+	}
+}
+
+func (ts *testEtcdServer) notify(event map[string]interface{}) {
+	for w := range ts.watchers {
+		w <- map[string]interface{}{
+			"result": map[string]interface{}{
+				"header": map[string]interface{}{"revision": strconv.FormatInt(ts.revision, 10)},
+				"events": []interface{}{event},
+			},
+		}
+	}
+}
+
+func (ts *testEtcdServer) waitForWatchers(n int) {
+	for {
+		ts.mux.Lock()
+		count := len(ts.watchers)
+		ts.mux.Unlock()
+		if count >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (ts *testEtcdServer) rangeCount() int {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	return ts.ranges
+}
+
+// ServeHTTP is a minimal stand-in of the /v3/kv/range and /v3/watch endpoints of the etcd JSON gateway.
+func (ts *testEtcdServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var req struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		ts.mux.Lock()
+		ts.ranges++
+		var kvs []map[string]interface{}
+		for k, v := range ts.kv {
+			if k == string(req.Key) || (len(req.RangeEnd) > 0 && k >= string(req.Key) && k < string(req.RangeEnd)) {
+				kvs = append(kvs, map[string]interface{}{"key": []byte(k), "value": []byte(v)})
+			}
+		}
+		header := map[string]interface{}{"revision": strconv.FormatInt(ts.revision, 10)}
+		ts.mux.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"header": header, "kvs": kvs})
+	case "/v3/watch":
+		var req struct {
+			CreateRequest struct {
+				Key           []byte `json:"key"`
+				RangeEnd      []byte `json:"range_end"`
+				StartRevision int64  `json:"start_revision,string"`
+			} `json:"create_request"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		enc := json.NewEncoder(w)
+		flusher := w.(http.Flusher)
+
+		ts.mux.Lock()
+		if req.CreateRequest.StartRevision <= ts.compacted {
+			compacted := ts.compacted
+			ts.mux.Unlock()
+			_ = enc.Encode(map[string]interface{}{"result": map[string]interface{}{
+				"canceled":         true,
+				"compact_revision": strconv.FormatInt(compacted, 10),
+			}})
+			return
+		}
+		events := make(chan map[string]interface{}, 10)
+		ts.watchers[events] = true
+		ts.mux.Unlock()
+
+		_ = enc.Encode(map[string]interface{}{"result": map[string]interface{}{"created": true}})
+		flusher.Flush()
+
+		prefix := string(req.CreateRequest.Key)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				result := event["result"].(map[string]interface{})
+				key := string(result["events"].([]interface{})[0].(map[string]interface{})["kv"].(map[string]interface{})["key"].([]byte))
+				if !strings.HasPrefix(key, prefix) {
+					continue
+				}
+				_ = enc.Encode(event)
+				flusher.Flush()
+			}
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
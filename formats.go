@@ -0,0 +1,271 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Decoder parses raw content into a settings map.
+type Decoder func(content []byte) (map[string]interface{}, error)
+
+// Encoder serializes a settings map into raw content.
+type Encoder func(settings map[string]interface{}) ([]byte, error)
+
+// Sniffer reports whether raw content looks like the given format.
+type Sniffer func(content []byte) bool
+
+type format struct {
+	name    string
+	exts    []string
+	decoder Decoder
+	encoder Encoder
+	sniff   Sniffer
+	builtin bool
+
+	// documents splits a multi-document stream, when the format supports it.
+	documents func(content []byte) ([]map[string]interface{}, error)
+
+	// positions returns the positions of the keys of each document, when the format supports it.
+	positions func(content []byte) []map[string]filePosition
+}
+
+type formatRegistry struct {
+	formats []*format
+	mux     sync.RWMutex
+}
+
+var formats = &formatRegistry{}
+
+func init() {
+	formats.register(&format{
+		name:      "json",
+		exts:      []string{string(jsonExtension)},
+		decoder:   decodeJSON,
+		encoder:   encodeJSON,
+		sniff:     sniffJSON,
+		builtin:   true,
+		positions: jsonPositions,
+	})
+	formats.register(&format{
+		name:      "yaml",
+		exts:      []string{string(yamlExtensionLong), string(yamlExtensionShort)},
+		decoder:   decodeYAML,
+		encoder:   encodeYAML,
+		sniff:     sniffYAML,
+		builtin:   true,
+		documents: decodeYAMLDocuments,
+		positions: yamlPositions,
+	})
+}
+
+// RegisterFormat makes a custom settings format available for New, Merge and NewFromContent.
+// The name is used as the content type, exts are the file extensions (e.g. ".xml")
+// belonging to the format. Registering an already known name replaces it.
+//
+// When the type of a content is guessed, sniff functions of custom formats
+// are tried before the built-in ones. A nil sniff means the format is never guessed.
+func RegisterFormat(name string, exts []string, decoder Decoder, encoder Encoder, sniff Sniffer) {
+	formats.register(&format{
+		name:    strings.ToLower(name),
+		exts:    normalizeExtensions(exts),
+		decoder: decoder,
+		encoder: encoder,
+		sniff:   sniff,
+	})
+}
+
+func (r *formatRegistry) register(f *format) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for i, registered := range r.formats {
+		if registered.name == f.name {
+			r.formats[i] = f
+			return
+		}
+	}
+	r.formats = append(r.formats, f)
+}
+
+func (r *formatRegistry) byName(name string) (*format, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	name = strings.ToLower(name)
+	for _, f := range r.formats {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func (r *formatRegistry) byExtension(ext string) (*format, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	ext = strings.ToLower(ext)
+	for _, f := range r.formats {
+		for _, e := range f.exts {
+			if e == ext {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// extensions returns the file extensions of all registered formats in the order they were registered.
+func (r *formatRegistry) extensions() []string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	var exts []string
+	for _, f := range r.formats {
+		exts = append(exts, f.exts...)
+	}
+	return exts
+}
+
+func (r *formatRegistry) byContent(content []byte) (*format, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	for _, builtin := range []bool{false, true} {
+		for _, f := range r.sniffOrder(content) {
+			if f.builtin == builtin && f.sniff != nil && f.sniff(content) {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// sniffOrder returns the formats in the order their sniff functions are tried.
+// A content starting with "<" is tried as XML first, because it can be a valid YAML content too.
+func (r *formatRegistry) sniffOrder(content []byte) []*format {
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		return r.formats
+	}
+
+	ordered := make([]*format, 0, len(r.formats))
+	for _, f := range r.formats {
+		if f.name == "xml" {
+			ordered = append([]*format{f}, ordered...)
+		} else {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered
+}
+
+func (f *format) decode(content []byte) (map[string]interface{}, error) {
+	if f.decoder == nil {
+		return nil, fmt.Errorf("format: %s :: has no decoder", f.name)
+	}
+	m, err := f.decoder(content)
+	if err != nil {
+		return nil, fmt.Errorf("While parsing config: %s", err)
+	}
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
+
+func (f *format) decodeDocuments(content []byte) ([]map[string]interface{}, error) {
+	if f.documents == nil {
+		m, err := f.decode(content)
+		if err != nil {
+			return nil, err
+		}
+		return []map[string]interface{}{m}, nil
+	}
+	docs, err := f.documents(content)
+	if err != nil {
+		return nil, fmt.Errorf("While parsing config: %s", err)
+	}
+	return docs, nil
+}
+
+func (f *format) encode(m map[string]interface{}) ([]byte, error) {
+	if f.encoder == nil {
+		return nil, fmt.Errorf("format: %s :: has no encoder", f.name)
+	}
+	return f.encoder(m)
+}
+
+func normalizeExtensions(exts []string) []string {
+	normalized := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
+func decodeJSON(content []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	err := json.Unmarshal(content, &m)
+	return m, err
+}
+
+func encodeJSON(m map[string]interface{}) ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func sniffJSON(content []byte) bool {
+	_, err := decodeJSON(content)
+	return err == nil
+}
+
+func decodeYAML(content []byte) (map[string]interface{}, error) {
+	content, err := resolveUnsetTags(content)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = yaml.Unmarshal(content, &m)
+	return m, err
+}
+
+func decodeYAMLDocuments(content []byte) ([]map[string]interface{}, error) {
+	content, err := resolveUnsetTags(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []map[string]interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var m map[string]interface{}
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			docs = append(docs, m)
+		}
+	}
+	return docs, nil
+}
+
+func encodeYAML(m map[string]interface{}) ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+func sniffYAML(content []byte) bool {
+	_, err := decodeYAML(content)
+	return err == nil
+}
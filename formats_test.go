@@ -0,0 +1,174 @@
+package settings
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitFormatsSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitFormatsSuite) TestRegisterFormat() {
+	initTestOk()
+	registerTestKVFormat()
+
+	err := ioutil.WriteFile(testKVFilePAth, []byte(testKVContent), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testYamlFilePAth).Merge(testKVFilePAth)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("kv.name")
+	u.Equal(nil, err)
+	u.Equal("from-kv", v)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	files, err := New(testFilePAth).GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Contains(files, "settings/test.kv")
+
+	sm = NewFromContent(testKVContent)
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("kv.name")
+	u.Equal(nil, err)
+	u.Equal("from-kv", v)
+
+	unregisterFormat("kv")
+	resetTest()
+}
+
+func (u unitFormatsSuite) TestGetExtensionByContent() {
+	u.Equal("json", getExtensionByContent(testJSONContent))
+	u.Equal("yaml", getExtensionByContent(testYamlContent))
+	u.Equal("unsupported", getExtensionByContent(testBadYamlContent))
+
+	registerTestKVFormat()
+	u.Equal("kv", getExtensionByContent(testKVContent))
+	u.Equal("yaml", getExtensionByContent(testYamlContent))
+	unregisterFormat("kv")
+}
+
+func (u unitFormatsSuite) TestUnsupportedExtension() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testKVFilePAth, []byte(testKVContent), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testKVFilePAth)
+	u.Equal(`Unsupported Config Type "kv"`, fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitFormatsSuite) TestEncode() {
+	f, ok := formats.byName("JSON")
+	u.Equal(true, ok)
+
+	b, err := f.encode(map[string]interface{}{"a": 1})
+	u.Equal(nil, err)
+	u.Equal("{\n  \"a\": 1\n}", string(b))
+
+	f, ok = formats.byExtension(".YML")
+	u.Equal(true, ok)
+	u.Equal("yaml", f.name)
+
+	b, err = f.encode(map[string]interface{}{"a": 1})
+	u.Equal(nil, err)
+	u.Equal("a: 1\n", string(b))
+}
+
+func TestFormatsUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitFormatsSuite))
+}
+
+func registerTestKVFormat() {
+	RegisterFormat("kv", []string{"kv"}, decodeTestKV, encodeTestKV, func(content []byte) bool {
+		return bytes.HasPrefix(bytes.TrimSpace(content), []byte("#kv"))
+	})
+}
+
+func unregisterFormat(name string) {
+	formats.mux.Lock()
+	defer formats.mux.Unlock()
+
+	for i, f := range formats.formats {
+		if f.name == name {
+			formats.formats = append(formats.formats[:i], formats.formats[i+1:]...)
+			return
+		}
+	}
+}
+
+func decodeTestKV(content []byte) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("kv: invalid line: %s", line)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return expandDottedKeys(m), nil
+}
+
+func encodeTestKV(m map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("#kv\n")
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s=%v\n", k, m[k])
+	}
+	return buf.Bytes(), nil
+}
+
+func expandDottedKeys(flat map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	for key, value := range flat {
+		path := strings.Split(key, ".")
+		node := m
+		for _, p := range path[:len(path)-1] {
+			next, ok := node[p].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				node[p] = next
+			}
+			node = next
+		}
+		node[path[len(path)-1]] = value
+	}
+	return m
+}
+
+var (
+	testKVContent = `#kv
+kv.name=from-kv
+kv.level=debug
+`
+
+	testKVFilePAth = "./settings/test.kv"
+)
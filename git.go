@@ -0,0 +1,178 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultGitRef      = "HEAD"
+	defaultGitInterval = 30 * time.Second
+)
+
+// GitOptions configures a git repository settings source.
+type GitOptions struct {
+	// Ref is a branch, a tag or a commit, defaults to HEAD.
+	Ref string
+
+	// Path is a settings file or a directory inside the repository, defaults to the root of it.
+	Path string
+
+	// Interval of polling the ref by AutoReload, defaults to 30 seconds.
+	Interval time.Duration
+}
+
+type gitSource struct {
+	repo string
+	opts GitOptions
+
+	mux    sync.Mutex
+	commit string
+	files  []string
+}
+
+// NewFromGit initializes settings from a local clone or a bare git repository at the given ref.
+func NewFromGit(repo string, opts GitOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeRemote(newGitSource(repo, opts), "NewFromGit")
+}
+
+// MergeGit merges initialized settings with a local clone or a bare git repository at the given ref.
+// The files are read from the objects of the repository, the working tree is not checked out.
+// AutoReload polls the ref, and reloads the settings when it points to another commit.
+func (s *Settings) MergeGit(repo string, opts GitOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeRemote(newGitSource(repo, opts), "MergeGit")
+}
+
+func newGitSource(repo string, opts GitOptions) *gitSource {
+	if opts.Ref == "" {
+		opts.Ref = defaultGitRef
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultGitInterval
+	}
+	opts.Path = strings.Trim(path.Clean("/"+filepath.ToSlash(opts.Path)), "/")
+	return &gitSource{repo: repo, opts: opts}
+}
+
+func (g *gitSource) name() string {
+	return g.repo + "@" + g.opts.Ref
+}
+
+func (g *gitSource) load() (map[string]interface{}, error) {
+	commit, err := g.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := g.git("ls-tree", "-r", "-z", "--name-only", commit, "--", g.pathspec())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Settings{Data: viper.New()}
+	var files []string
+	for _, file := range strings.Split(string(out), "\x00") {
+		if file == "" || !isSettingsFile(file) {
+			continue
+		}
+		b, err := g.git("cat-file", "blob", commit+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.mergeFile(file, b); err != nil {
+			return nil, fmt.Errorf("%s@%s: %s", file, commit, err)
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("git: %s@%s :: cannot find settings files under %q", g.repo, g.opts.Ref, g.opts.Path)
+	}
+
+	g.mux.Lock()
+	g.commit, g.files = commit, files
+	g.mux.Unlock()
+
+	return s.Data.AllSettings(), nil
+}
+
+func (g *gitSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	ticker := time.NewTicker(g.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			commit, err := g.resolve()
+			if err != nil {
+				report(err)
+				continue
+			}
+
+			g.mux.Lock()
+			changed := commit != g.commit
+			g.mux.Unlock()
+
+			if changed {
+				reload()
+			}
+		}
+	}
+}
+
+// fileNames returns the loaded files of the repository, each with the commit it came from.
+func (g *gitSource) fileNames() []string {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	names := make([]string, 0, len(g.files))
+	for _, file := range g.files {
+		names = append(names, fmt.Sprintf("%s@%s", filepath.Join(g.repo, file), g.commit))
+	}
+	return names
+}
+
+// resolve returns the commit the ref currently points to.
+func (g *gitSource) resolve() (string, error) {
+	out, err := g.git("rev-parse", "--verify", "--end-of-options", g.opts.Ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (g *gitSource) pathspec() string {
+	if g.opts.Path == "" {
+		return "."
+	}
+	return g.opts.Path
+}
+
+func (g *gitSource) git(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", g.repo}, args...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git: %s :: %s", g.repo, msg)
+		}
+		return nil, fmt.Errorf("git: %s :: %s", g.repo, err)
+	}
+	return out, nil
+}
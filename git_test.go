@@ -0,0 +1,157 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitGitSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitGitSuite) TestNewFromGit() {
+	initTestOk()
+	first := initTestGitRepo(u)
+
+	u.commitTestGitFile("config/app.yaml", "service:\n  name: Changed\n")
+	u.testGit("tag", "v2")
+
+	sm := NewFromGit(testGitRepoPath, GitOptions{Ref: "v1", Path: "config"})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("GitService", v)
+
+	i, err := sm.GetInt("server.port")
+	u.Equal(nil, err)
+	u.Equal(8080, i)
+
+	isSet, err := sm.IsSet("readme")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+
+	fileNames, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{
+		filepath.Join(testGitRepoPath, "config/app.yaml") + "@" + first,
+		filepath.Join(testGitRepoPath, "config/server.yml") + "@" + first,
+	}, fileNames)
+
+	sm = NewFromGit(testGitRepoPath, GitOptions{Ref: "v2", Path: "config/app.yaml"})
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Changed", v)
+
+	isSet, err = sm.IsSet("server.port")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+
+	bare := testGitRepoPath + ".git"
+	out, err := exec.Command("git", "clone", "-q", "--bare", testGitRepoPath, bare).CombinedOutput()
+	u.Equal(nil, err, string(out))
+
+	sm = New(testYamlFilePAth).MergeGit(bare, GitOptions{Ref: first})
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("GitService", v)
+
+	fileNames, err = sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal(3, len(fileNames))
+	u.Equal(filepath.Clean(testYamlFilePAth), fileNames[0])
+
+	sm = NewFromGit(testGitRepoPath, GitOptions{Ref: "missing"})
+	u.Equal(true, strings.HasPrefix(fmt.Sprint(sm.Error), "settings.NewFromGit :: git: ./settings/repo :: fatal:"))
+
+	sm = NewFromGit(testGitRepoPath, GitOptions{Path: "missing"})
+	u.Equal(`settings.NewFromGit :: git: ./settings/repo@HEAD :: cannot find settings files under "missing"`, fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitGitSuite) TestGitAutoReload() {
+	initTestOk()
+	initTestGitRepo(u)
+
+	reloaded := make(chan struct{}, 10)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := NewFromGit(testGitRepoPath, GitOptions{Ref: "master", Interval: 10 * time.Millisecond})
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+
+	u.commitTestGitFile("config/app.yaml", "service:\n  name: Reloaded\n")
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+	sm.StopAutoReload()
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Reloaded", v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+	resetTest()
+}
+
+func TestGitUnitSuite(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	suite.Run(t, new(unitGitSuite))
+}
+
+// initTestGitRepo creates a repository with a tagged commit, and returns the hash of it.
+func initTestGitRepo(u unitGitSuite) string {
+	u.testGit("init", "-q", "-b", "master")
+	u.commitTestGitFile("README.md", "readme: true\n")
+	u.commitTestGitFile("config/server.yml", "server:\n  port: 8080\n")
+	u.commitTestGitFile("config/app.yaml", "service:\n  name: GitService\n")
+	u.testGit("tag", "v1")
+	return strings.TrimSpace(u.testGit("rev-parse", "HEAD"))
+}
+
+func (u unitGitSuite) commitTestGitFile(file, content string) {
+	file = filepath.Join(testGitRepoPath, file)
+	u.Equal(nil, os.MkdirAll(filepath.Dir(file), os.ModePerm))
+	u.Equal(nil, ioutil.WriteFile(file, []byte(content), os.ModePerm))
+
+	u.testGit("add", "-A")
+	u.testGit("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", file)
+}
+
+func (u unitGitSuite) testGit(args ...string) string {
+	u.Equal(nil, os.MkdirAll(testGitRepoPath, os.ModePerm))
+
+	out, err := exec.Command("git", append([]string{"-C", testGitRepoPath}, args...)...).CombinedOutput()
+	u.Equal(nil, err, string(out))
+	return string(out)
+}
+
+var (
+	testGitRepoPath = "./settings/repo"
+)
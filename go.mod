@@ -1,6 +1,6 @@
 module github.com/takattila/settings-manager
 
-go 1.13
+go 1.16
 
 require (
 	github.com/fsnotify/fsnotify v1.4.7
@@ -8,4 +8,5 @@ require (
 	github.com/spf13/viper v1.6.2
 	github.com/stretchr/testify v1.4.0
 	gopkg.in/yaml.v2 v2.2.8
+	gopkg.in/yaml.v3 v3.0.1
 )
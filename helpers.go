@@ -1,19 +1,19 @@
 package settings
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/fsnotify/fsnotify"
-
-	"gopkg.in/yaml.v2"
+	"github.com/spf13/viper"
 )
 
 type supportedExtension string
@@ -26,14 +26,59 @@ const (
 
 var triggerReload = func(s *Settings) {
 	s.Data.OnConfigChange(func(in fsnotify.Event) {
+		if s.isOwnWrite(in.Name) {
+			return
+		}
 		s.Reload()
 		log.Println("settings.AutoReload", "settings reloaded")
 	})
 }
 
+const autoReloadErrorsSize = 16
+
+var triggerRemoteReload = func(s *Settings) func() {
+	return func() {
+		s.Reload()
+		log.Println("settings.AutoReload", "settings reloaded")
+	}
+}
+
+// watchSource reloads the settings on the events of a watched source, and reports its errors.
+func (s *Settings) watchSource(events <-chan Event) {
+	reload := triggerRemoteReload(s)
+	for e := range events {
+		if e.Err != nil {
+			s.reportError(e.Err)
+		} else {
+			reload()
+		}
+	}
+}
+
+func (s *Settings) reportError(err error) {
+	log.Println("settings.AutoReload", err)
+
+	s.mux.Lock()
+	errs := s.errs
+	s.mux.Unlock()
+
+	select {
+	case errs <- err:
+	default:
+	}
+}
+
 func (s *Settings) load(settingsFile string) *Settings {
+	return s.loadDirectory(settingsFile, DirectoryOptions{})
+}
+
+func (s *Settings) loadDirectory(settingsFile string, opts DirectoryOptions) *Settings {
 	if isDirectory(settingsFile) {
-		for _, file := range listFilesUnderDirectory(settingsFile) {
+		files, err := listFilesUnderDirectory(settingsFile, opts)
+		if err != nil {
+			return &Settings{Error: err}
+		}
+		for _, file := range files {
 			if err := s.load(file).Error; err != nil {
 				return &Settings{Error: err}
 			}
@@ -43,13 +88,108 @@ func (s *Settings) load(settingsFile string) *Settings {
 		if err != nil {
 			return &Settings{Error: err}
 		}
-		s.Data.SetConfigType(getExtensionByFileName(settingsFile))
+		if err := s.mergeIncluding(nil, settingsFile, b); err != nil {
+			return &Settings{Error: err}
+		}
+		s.appendFileName(settingsFile)
+	}
+	return s
+}
 
-		err = s.Data.MergeConfig(bytes.NewBuffer(b))
+func (s *Settings) loadFS(fsys fs.FS, settingsFile string) *Settings {
+	settingsFile = path.Clean(settingsFile)
+	if isDirectoryFS(fsys, settingsFile) {
+		files, err := listFilesUnderDirectoryFS(fsys, settingsFile)
 		if err != nil {
 			return &Settings{Error: err}
 		}
-		s.appendFileName(settingsFile)
+		for _, file := range files {
+			if err := s.loadFS(fsys, file).Error; err != nil {
+				return &Settings{Error: err}
+			}
+		}
+	} else {
+		b, err := fs.ReadFile(fsys, settingsFile)
+		if err != nil {
+			return &Settings{Error: err}
+		}
+		if err := s.mergeIncluding(fsys, settingsFile, b); err != nil {
+			return &Settings{Error: err}
+		}
+	}
+	return s
+}
+
+func (s *Settings) mergeFile(fileName string, b []byte) error {
+	previous := s.merging
+	defer func() {
+		s.merging = previous
+	}()
+	if s.merging.origin.File == "" {
+		s.merging.origin.File = fileName
+	}
+	s.merging.positions, s.merging.document = nil, 0
+
+	if isArchive(fileName) {
+		return s.mergeArchive(fileName, b)
+	}
+	if isGzip(fileName) {
+		content, err := gunzip(b)
+		if err != nil {
+			return fmt.Errorf("%s: %s", fileName, err)
+		}
+		return s.mergeFile(strings.TrimSuffix(fileName, filepath.Ext(fileName)), content)
+	}
+
+	f, ok := formats.byExtension(filepath.Ext(fileName))
+	if !ok {
+		return fmt.Errorf("Unsupported Config Type %q", getExtensionByFileName(fileName))
+	}
+
+	docs, err := f.decodeDocuments(b)
+	if err != nil {
+		return err
+	}
+	if f.positions != nil {
+		if positions := f.positions(b); len(positions) == len(docs) {
+			s.merging.positions = positions
+		}
+	}
+	return s.mergeDocuments(docs)
+}
+
+// rebuild replays all layers and the overrides into a new settings data, which replaces the current one on success.
+func (s *Settings) rebuild() error {
+	r := &Settings{Data: viper.New(), profile: s.profile, strategies: s.strategies}
+
+	for i, l := range s.layers {
+		if err := r.apply(l.source, i); err != nil {
+			return err
+		}
+	}
+
+	s.Data = r.Data
+	s.fileNames = r.fileNames
+	s.profiles = r.profiles
+	s.origins = r.origins
+	s.applyOverrides()
+	return nil
+}
+
+func (s *Settings) mergeContent(content, contentType, funcName string) *Settings {
+	f, ok := formats.byName(contentType)
+	if contentType == "" {
+		f, ok = formats.byContent([]byte(content))
+	}
+	if !ok {
+		if contentType == "" {
+			return &Settings{Error: fmt.Errorf("settings.%s :: unsupported content type", funcName)}
+		}
+		return &Settings{Error: fmt.Errorf("settings.%s :: unsupported content type: %s", funcName, contentType)}
+	}
+
+	if err := s.addSource(&contentSource{content: content, format: f}, 0); err != nil {
+		return &Settings{Error: fmt.Errorf("settings.%s :: %s", funcName, err)}
 	}
 	return s
 }
@@ -112,25 +252,36 @@ func (s *Settings) checkIntSlice(key string) error {
 	return nil
 }
 
-func listFilesUnderDirectory(dir string) (files []string) {
-	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if !isDirectory(path) {
-			if supportedExtension(filepath.Ext(path)).validateExtension() {
-				files = append(files, path)
-			}
+// setNested sets a value in a nested map, creating the missing parent maps along the path.
+func setNested(m map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
 		}
-		return nil
-	})
-	return
+		m = next
+	}
+	m[path[len(path)-1]] = value
 }
 
-func getExtensionByContent(source string) string {
-	var obj map[string]interface{}
-	if err := json.Unmarshal([]byte(source), &obj); err == nil {
-		return "json"
+// inferScalar converts a raw text to bool, int or float64 where possible.
+func inferScalar(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
 	}
-	if err := yaml.Unmarshal([]byte(source), &obj); err == nil {
-		return "yaml"
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+func getExtensionByContent(source string) string {
+	if f, ok := formats.byContent([]byte(source)); ok {
+		return f.name
 	}
 	return "unsupported"
 }
@@ -151,6 +302,14 @@ func isDirectory(path string) bool {
 	return false
 }
 
+func isDirectoryFS(fsys fs.FS, path string) bool {
+	fi, err := fs.Stat(fsys, path)
+	if err != nil {
+		return false
+	}
+	return fi.IsDir()
+}
+
 func (s *Settings) appendFileName(fileName string) {
 	s.fileNames = append(s.fileNames, filepath.Clean(fileName))
 	s.fileNames = makeUniqueSlice(s.fileNames)
@@ -171,9 +330,6 @@ func makeUniqueSlice(s []string) []string {
 }
 
 func (e supportedExtension) validateExtension() bool {
-	switch e {
-	case jsonExtension, yamlExtensionLong, yamlExtensionShort:
-		return true
-	}
-	return false
+	_, ok := formats.byExtension(string(e))
+	return ok
 }
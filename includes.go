@@ -0,0 +1,170 @@
+package settings
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// includeKey is a top-level key of a document, which includes other settings files,
+// e.g. "$include: [common.yaml, secrets/*.yaml]".
+const includeKey = "$include"
+
+// includeContext describes the file being merged, so that its includes can be resolved relative to it.
+type includeContext struct {
+	// fsys is the file system of the files, it is nil for the files of the operating system.
+	fsys fs.FS
+
+	// chain are the files being merged, the including ones first.
+	chain []string
+}
+
+// include is an entry of an include directive.
+type include struct {
+	path     string
+	optional bool
+}
+
+// mergeIncluding merges a settings file, which may include other files.
+func (s *Settings) mergeIncluding(fsys fs.FS, file string, b []byte) error {
+	previous := s.merging.include
+	defer func() {
+		s.merging.include = previous
+	}()
+
+	var chain []string
+	if previous != nil {
+		chain = previous.chain
+	}
+	clean := filepath.Clean(file)
+	if fsys != nil {
+		clean = path.Clean(file)
+	}
+	s.merging.include = &includeContext{fsys: fsys, chain: append(chain[:len(chain):len(chain)], clean)}
+	return s.mergeFile(file, b)
+}
+
+// mergeIncludes merges the files included by a document, before the document itself is merged.
+//
+// The paths are relative to the including file, or to the working directory for contents.
+// A path can be a glob pattern, which may match no files. Other paths have to exist, unless they are optional:
+// "$include: [common.yaml, {path: local.yaml, optional: true}]".
+func (s *Settings) mergeIncludes(directive interface{}) error {
+	c := s.merging.include
+	if c == nil {
+		return fmt.Errorf("%s :: is not supported by this source", includeKey)
+	}
+
+	includes, err := parseIncludes(directive)
+	if err != nil {
+		return fmt.Errorf("%s :: %s", includeKey, err)
+	}
+
+	for _, inc := range includes {
+		files, err := c.resolve(inc.path)
+		if err != nil {
+			return fmt.Errorf("%s :: %s :: %s", includeKey, inc.path, err)
+		}
+		if len(files) == 0 && !inc.optional && !hasGlobMeta(inc.path) {
+			return fmt.Errorf("%s :: %s :: cannot find file", includeKey, inc.path)
+		}
+
+		for _, file := range files {
+			if err := s.mergeIncluded(c, file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Settings) mergeIncluded(c *includeContext, file string) error {
+	for _, f := range c.chain {
+		if f == file {
+			chain := append(c.chain[:len(c.chain):len(c.chain)], file)
+			return fmt.Errorf("%s :: include cycle: %s", includeKey, strings.Join(chain, " -> "))
+		}
+	}
+
+	previous := s.merging.origin.File
+	s.merging.origin.File = ""
+	defer func() {
+		s.merging.origin.File = previous
+	}()
+
+	var err error
+	if c.fsys != nil {
+		err = s.loadFS(c.fsys, file).Error
+	} else {
+		err = s.load(file).Error
+	}
+	if err != nil && !strings.HasPrefix(err.Error(), includeKey+" :: ") {
+		return fmt.Errorf("%s :: %s: %s", includeKey, file, err)
+	}
+	return err
+}
+
+// resolve returns the files matching a path relative to the including file.
+// Hidden files and editor backups are not matched by glob patterns.
+func (c *includeContext) resolve(p string) ([]string, error) {
+	var (
+		files []string
+		err   error
+	)
+	if c.fsys != nil {
+		dir := "."
+		if len(c.chain) > 0 {
+			dir = path.Dir(c.chain[len(c.chain)-1])
+		}
+		files, err = fs.Glob(c.fsys, path.Join(dir, p))
+	} else {
+		if !filepath.IsAbs(p) && len(c.chain) > 0 {
+			p = filepath.Join(filepath.Dir(c.chain[len(c.chain)-1]), p)
+		}
+		files, err = filepath.Glob(filepath.Clean(p))
+	}
+	if err != nil || !hasGlobMeta(p) {
+		return files, err
+	}
+
+	kept := files[:0]
+	for _, f := range files {
+		if !isIgnoredFile(path.Base(filepath.ToSlash(f))) {
+			kept = append(kept, f)
+		}
+	}
+	return kept, nil
+}
+
+func parseIncludes(directive interface{}) ([]include, error) {
+	entries, ok := toSlice(directive)
+	if !ok {
+		entries = []interface{}{directive}
+	}
+
+	var includes []include
+	for _, e := range entries {
+		if p, ok := e.(string); ok && p != "" {
+			includes = append(includes, include{path: p})
+			continue
+		}
+
+		m, ok := toStringMap(e)
+		if !ok {
+			return nil, fmt.Errorf("%v :: should be a path or a map of path and optional", e)
+		}
+		p, _ := m["path"].(string)
+		optional, isBool := m["optional"].(bool)
+		if p == "" || (m["optional"] != nil && !isBool) {
+			return nil, fmt.Errorf("%v :: should be a path or a map of path and optional", e)
+		}
+		includes = append(includes, include{path: p, optional: optional})
+	}
+	return includes, nil
+}
+
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[`)
+}
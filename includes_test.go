@@ -0,0 +1,157 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitIncludesSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitIncludesSuite) TestInclude() {
+	initTestIncludes(u, map[string]string{
+		"app.yaml": `
+$include:
+  - common.yaml
+  - secrets/*.yaml
+  - path: local.yaml
+    optional: true
+db:
+  host: app
+`,
+		"common.yaml":          "db:\n  host: common\n  port: \"5432\"\nlevel: common\n",
+		"secrets/db.yaml":      "db:\n  password: secret\n",
+		"secrets/.hidden.yaml": "db:\n  password: hidden\n",
+		"secrets/z.yaml":       "$include: ../nested/level.json\n",
+		"nested/level.json":    `{"level": "nested"}`,
+	})
+
+	sm := New(filepath.Join(testDirPath, "app.yaml"))
+	u.Equal(nil, sm.Error)
+
+	for key, expected := range map[string]string{
+		"db.host":     "app",
+		"db.port":     "5432",
+		"db.password": "secret",
+		"level":       "nested",
+	} {
+		v, err := sm.GetString(key)
+		u.Equal(nil, err)
+		u.Equal(expected, v, key)
+	}
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.NotContains(keys, includeKey)
+
+	files, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{
+		filepath.Join(testDirPath, "common.yaml"),
+		filepath.Join(testDirPath, "secrets/db.yaml"),
+		filepath.Join(testDirPath, "nested/level.json"),
+		filepath.Join(testDirPath, "secrets/z.yaml"),
+		filepath.Join(testDirPath, "app.yaml"),
+	}, files)
+
+	o, err := sm.Origin("db.port")
+	u.Equal(nil, err)
+	u.Equal(filepath.Join(testDirPath, "common.yaml"), o.File)
+	u.Equal(3, o.Line)
+
+	u.Equal(nil, ioutil.WriteFile(filepath.Join(testDirPath, "local.yaml"), []byte("level: local\n"), 0644))
+	sm.Reload()
+
+	v, err := sm.GetString("level")
+	u.Equal(nil, err)
+	u.Equal("local", v)
+
+	resetTest()
+}
+
+func (u unitIncludesSuite) TestIncludeErrors() {
+	initTestIncludes(u, map[string]string{
+		"a.yaml":       "$include: b.yaml\n",
+		"b.yaml":       "$include: [c/../a.yaml]\n",
+		"missing.yaml": "$include: [none.yaml, none/*.yaml]\n",
+		"bad.yaml":     "$include: [{optional: true}]\n",
+		"broken.yaml":  "$include: bad.json\n",
+		"bad.json":     "{",
+	})
+
+	a, b := filepath.Join(testDirPath, "a.yaml"), filepath.Join(testDirPath, "b.yaml")
+	sm := New(a)
+	u.Equal(fmt.Sprintf("$include :: include cycle: %s -> %s -> %s", a, b, a), fmt.Sprint(sm.Error))
+
+	sm = New(filepath.Join(testDirPath, "missing.yaml"))
+	u.Equal("$include :: none.yaml :: cannot find file", fmt.Sprint(sm.Error))
+
+	sm = New(filepath.Join(testDirPath, "bad.yaml"))
+	u.Equal("$include :: map[optional:true] :: should be a path or a map of path and optional", fmt.Sprint(sm.Error))
+
+	sm = New(filepath.Join(testDirPath, "broken.yaml"))
+	u.Contains(fmt.Sprint(sm.Error), "$include :: settings/bad.json: While parsing config:")
+
+	sm = NewFromContent(testYamlContent).MergeContent(`{"$include": "settings/a.yaml"}`, "json")
+	u.Contains(fmt.Sprint(sm.Error), "include cycle: settings/a.yaml -> settings/b.yaml -> settings/a.yaml")
+
+	archive := filepath.Join(testDirPath, "bundle.zip")
+	u.Equal(nil, ioutil.WriteFile(archive, zipHelper(unitArchivesSuite{u.Suite}, []archiveMember{{name: "app.yaml", content: []byte("$include: a.yaml\n")}}), 0644))
+	sm = New(archive)
+	u.Contains(fmt.Sprint(sm.Error), "$include :: is not supported by this source")
+
+	resetTest()
+}
+
+func (u unitIncludesSuite) TestIncludeFS() {
+	fsys := fstest.MapFS{
+		"conf/app.yaml":      {Data: []byte("$include: [common/*.yaml]\nname: app\n")},
+		"conf/common/a.yaml": {Data: []byte("name: common\nport: 80\n")},
+	}
+
+	sm := NewFromFS(fsys, "conf/app.yaml")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("name")
+	u.Equal(nil, err)
+	u.Equal("app", v)
+
+	i, err := sm.GetInt("port")
+	u.Equal(nil, err)
+	u.Equal(80, i)
+}
+
+func (u unitIncludesSuite) TestParseIncludes() {
+	includes, err := parseIncludes([]interface{}{"a.yaml", map[interface{}]interface{}{"path": "b.yaml", "optional": true}})
+	u.Equal(nil, err)
+	u.Equal([]include{{path: "a.yaml"}, {path: "b.yaml", optional: true}}, includes)
+
+	_, err = parseIncludes(map[string]interface{}{"path": "a.yaml", "optional": "yes"})
+	u.NotEqual(nil, err)
+
+	_, err = parseIncludes(1)
+	u.Equal("1 :: should be a path or a map of path and optional", fmt.Sprint(err))
+}
+
+func TestIncludesUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitIncludesSuite))
+}
+
+func initTestIncludes(u unitIncludesSuite, files map[string]string) {
+	initTestOk()
+
+	for file, content := range files {
+		name := filepath.Join(testDirPath, file)
+		u.Equal(nil, os.MkdirAll(filepath.Dir(name), os.ModePerm))
+		u.Equal(nil, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+}
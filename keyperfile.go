@@ -0,0 +1,134 @@
+package settings
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+type keyPerFileSource struct {
+	dir    string
+	prefix string
+
+	mux  sync.Mutex
+	last map[string]interface{}
+}
+
+// NewFromKeyPerFile initializes settings from a directory holding one file per key, see MergeKeyPerFile.
+func NewFromKeyPerFile(dir, prefix string) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeRemote(&keyPerFileSource{dir: dir, prefix: prefix}, "NewFromKeyPerFile")
+}
+
+// MergeKeyPerFile merges initialized settings with a directory holding one file per key,
+// like Docker secrets, Kubernetes Secret volumes or $CREDENTIALS_DIRECTORY of systemd.
+//
+// The name of a file becomes the key under the given prefix, dots in the name are nested keys.
+// The content of a file is the value as a string, trailing newlines trimmed.
+// Hidden files and directories are skipped. AutoReload watches the directory for changes.
+func (s *Settings) MergeKeyPerFile(dir, prefix string) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeRemote(&keyPerFileSource{dir: dir, prefix: prefix}, "MergeKeyPerFile")
+}
+
+func (k *keyPerFileSource) name() string {
+	return k.dir
+}
+
+func (k *keyPerFileSource) load() (map[string]interface{}, error) {
+	m, err := k.read()
+	if err != nil {
+		return nil, err
+	}
+
+	k.mux.Lock()
+	k.last = m
+	k.mux.Unlock()
+
+	return copyMap(m), nil
+}
+
+func (k *keyPerFileSource) read() (map[string]interface{}, error) {
+	entries, err := ioutil.ReadDir(k.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		file := filepath.Join(k.dir, name)
+		fi, err := os.Stat(file)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		path := strings.Split(name, ".")
+		if k.prefix != "" {
+			path = append(strings.Split(k.prefix, "."), path...)
+		}
+		setNested(m, path, strings.TrimRight(string(b), "\r\n"))
+	}
+	return m, nil
+}
+
+func (k *keyPerFileSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		report(err)
+		return
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	if err := watcher.Add(k.dir); err != nil {
+		report(err)
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case err := <-watcher.Errors:
+			report(err)
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			m, err := k.read()
+			if err != nil {
+				report(err)
+				continue
+			}
+
+			k.mux.Lock()
+			changed := !reflect.DeepEqual(k.last, m)
+			k.mux.Unlock()
+
+			if changed {
+				reload()
+			}
+		}
+	}
+}
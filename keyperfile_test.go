@@ -0,0 +1,122 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitKeyPerFileSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitKeyPerFileSuite) TestMergeKeyPerFile() {
+	initTestOk()
+	initTestSecrets(u)
+
+	sm := New(testYamlFilePAth).MergeKeyPerFile(testSecretsDirPath, "secrets")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("secrets.db.password")
+	u.Equal(nil, err)
+	u.Equal("s3cr3t", v)
+
+	v, err = sm.GetString("secrets.api_key")
+	u.Equal(nil, err)
+	u.Equal("0123", v)
+
+	v, err = sm.GetString("secrets.linked")
+	u.Equal(nil, err)
+	u.Equal("from ..data", v)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	isSet, err := sm.IsSet("secrets.hidden")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+
+	sm = NewFromKeyPerFile(testSecretsDirPath, "")
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("db.password")
+	u.Equal(nil, err)
+	u.Equal("s3cr3t", v)
+
+	sm = NewFromKeyPerFile("./settings/missing", "")
+	u.Equal("settings.NewFromKeyPerFile :: open ./settings/missing: no such file or directory", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitKeyPerFileSuite) TestKeyPerFileAutoReload() {
+	initTestOk()
+	initTestSecrets(u)
+
+	reloaded := make(chan struct{}, 10)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := NewFromKeyPerFile(testSecretsDirPath, "secrets")
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+	time.Sleep(10 * time.Millisecond)
+
+	err := ioutil.WriteFile(filepath.Join(testSecretsDirPath, "db.password"), []byte("rotated\n"), os.ModePerm)
+	u.Equal(nil, err)
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+	sm.StopAutoReload()
+
+	v, err := sm.GetString("secrets.db.password")
+	u.Equal(nil, err)
+	u.Equal("rotated", v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+	resetTest()
+}
+
+func TestKeyPerFileUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitKeyPerFileSuite))
+}
+
+// initTestSecrets creates a directory like a Kubernetes Secret volume,
+// where the keys are symlinks into a hidden ..data directory.
+func initTestSecrets(u unitKeyPerFileSuite) {
+	dataDir := filepath.Join(testSecretsDirPath, "..2020_01_01")
+	u.Equal(nil, os.MkdirAll(dataDir, os.ModePerm))
+
+	files := map[string]string{
+		filepath.Join(testSecretsDirPath, "db.password"): "s3cr3t\n",
+		filepath.Join(testSecretsDirPath, "api_key"):     "0123\r\n",
+		filepath.Join(testSecretsDirPath, ".hidden"):     "hidden",
+		filepath.Join(dataDir, "linked"):                 "from ..data\n",
+	}
+	for file, content := range files {
+		u.Equal(nil, ioutil.WriteFile(file, []byte(content), os.ModePerm))
+	}
+
+	u.Equal(nil, os.Symlink("..2020_01_01", filepath.Join(testSecretsDirPath, "..data")))
+	u.Equal(nil, os.Symlink(filepath.Join("..data", "linked"), filepath.Join(testSecretsDirPath, "linked")))
+}
+
+var (
+	testSecretsDirPath = "./settings/secrets"
+)
@@ -0,0 +1,313 @@
+package settings
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// mergeDirectiveKey is a top-level key of a document, which declares the merge strategies of its arrays,
+// e.g. "$merge: {plugins: append, users: merge-by-key:name}".
+const mergeDirectiveKey = "$merge"
+
+// MergeStrategy decides, how an array is merged with the array of the same key merged before.
+type MergeStrategy struct {
+	name  string
+	field string
+}
+
+var (
+	// MergeReplace replaces the array merged before, it is the default strategy.
+	MergeReplace = MergeStrategy{name: "replace"}
+
+	// MergeAppend appends the items to the array merged before.
+	MergeAppend = MergeStrategy{name: "append"}
+
+	// MergePrepend prepends the items to the array merged before.
+	MergePrepend = MergeStrategy{name: "prepend"}
+
+	// MergeUnion appends the items, which are not in the array merged before.
+	MergeUnion = MergeStrategy{name: "union"}
+)
+
+// MergeByKey merges the objects of the arrays having the same value of the given identity field, e.g. "name".
+// The other items are appended.
+func MergeByKey(field string) MergeStrategy {
+	return MergeStrategy{name: "merge-by-key", field: field}
+}
+
+// String returns the strategy in the form used by the merge directive of a document.
+func (m MergeStrategy) String() string {
+	if m.field != "" {
+		return m.name + ":" + m.field
+	}
+	return m.name
+}
+
+// pathStrategy is a merge strategy of the arrays under a path.
+type pathStrategy struct {
+	path     string
+	strategy MergeStrategy
+}
+
+// SetMergeStrategy sets the merge strategy of the arrays under a dotted path, e.g. "server.plugins".
+// A "*" element of the path matches any key. The settings data is re-read with the new strategy.
+//
+// A document can declare the strategies of its own arrays with a top-level "$merge" key,
+// which maps the paths to strategies: replace, append, prepend, union or merge-by-key:<field>.
+// The strategies of a document take precedence over the ones set by SetMergeStrategy.
+func (s *Settings) SetMergeStrategy(path string, strategy MergeStrategy) *Settings {
+	if s.Error != nil {
+		return &Settings{Error: fmt.Errorf("settings.SetMergeStrategy :: %s", s.Error)}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	previous := s.strategies
+	path = strings.ToLower(path)
+
+	s.strategies = nil
+	for _, ps := range previous {
+		if ps.path != path {
+			s.strategies = append(s.strategies, ps)
+		}
+	}
+	s.strategies = append(s.strategies, pathStrategy{path: path, strategy: strategy})
+
+	if err := s.rebuild(); err != nil {
+		s.strategies = previous
+		return &Settings{Error: fmt.Errorf("settings.SetMergeStrategy :: %s", err)}
+	}
+	return s
+}
+
+// mergeConfigMap merges a document into the settings data, deleting the keys marked by the document
+// and merging its arrays by the merge strategies.
+func (s *Settings) mergeConfigMap(doc map[string]interface{}) error {
+	directives, err := parseMergeDirective(doc[mergeDirectiveKey])
+	if err != nil {
+		return err
+	}
+	if _, ok := doc[mergeDirectiveKey]; ok {
+		c := make(map[string]interface{}, len(doc))
+		for k, v := range doc {
+			if k != mergeDirectiveKey {
+				c[k] = v
+			}
+		}
+		doc = c
+	}
+
+	doc, err = s.deleteMarked(doc)
+	if err != nil {
+		return err
+	}
+
+	if len(directives) > 0 || len(s.strategies) > 0 {
+		doc = s.mergeArrays(doc, nil, directives).(map[string]interface{})
+	}
+	s.recordOrigins(doc, nil)
+	return s.Data.MergeConfigMap(doc)
+}
+
+// mergeArrays returns a copy of a value, where the arrays are merged with the ones merged before.
+func (s *Settings) mergeArrays(value interface{}, path []string, directives []pathStrategy) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			c[k] = s.mergeArrays(val, append(path[:len(path):len(path)], k), directives)
+		}
+		return c
+	case map[interface{}]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			key := fmt.Sprint(k)
+			c[key] = s.mergeArrays(val, append(path[:len(path):len(path)], key), directives)
+		}
+		return c
+	}
+
+	items, ok := toSlice(value)
+	if !ok {
+		return value
+	}
+	key := strings.ToLower(strings.Join(path, "."))
+	strategy, ok := findStrategy(directives, key)
+	if !ok {
+		strategy, ok = findStrategy(s.strategies, key)
+	}
+	if !ok || strategy == MergeReplace {
+		return value
+	}
+	merged, ok := toSlice(s.Data.Get(key))
+	if !ok {
+		return value
+	}
+	return strategy.merge(merged, items)
+}
+
+func (m MergeStrategy) merge(merged, items []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(merged)+len(items))
+
+	switch m.name {
+	case "append":
+		result = append(append(result, merged...), items...)
+	case "prepend":
+		result = append(append(result, items...), merged...)
+	case "union":
+		seen := map[string]bool{}
+		for _, item := range append(merged[:len(merged):len(merged)], items...) {
+			if id := fmt.Sprint(item); !seen[id] {
+				seen[id] = true
+				result = append(result, item)
+			}
+		}
+	case "merge-by-key":
+		index := map[string]int{}
+		for _, item := range merged {
+			if id, ok := identity(item, m.field); ok {
+				index[id] = len(result)
+			}
+			result = append(result, item)
+		}
+		for _, item := range items {
+			if id, ok := identity(item, m.field); ok {
+				if i, found := index[id]; found {
+					result[i] = mergeMaps(result[i], item)
+					continue
+				}
+				index[id] = len(result)
+			}
+			result = append(result, item)
+		}
+	default:
+		result = append(result, items...)
+	}
+	return result
+}
+
+func identity(item interface{}, field string) (string, bool) {
+	m, ok := toStringMap(item)
+	if !ok {
+		return "", false
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, field) {
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+// mergeMaps merges the keys of src into a copy of dst, the nested maps are merged recursively.
+func mergeMaps(dst, src interface{}) interface{} {
+	d, ok := toStringMap(dst)
+	if !ok {
+		return src
+	}
+	sm, ok := toStringMap(src)
+	if !ok {
+		return src
+	}
+
+	result := make(map[string]interface{}, len(d)+len(sm))
+	for k, v := range d {
+		result[k] = v
+	}
+	for k, v := range sm {
+		if _, isMap := toStringMap(v); isMap {
+			if old, found := result[k]; found {
+				v = mergeMaps(old, v)
+			}
+		}
+		result[k] = v
+	}
+	return result
+}
+
+func toSlice(value interface{}) ([]interface{}, bool) {
+	if items, ok := value.([]interface{}); ok {
+		return items, true
+	}
+	v := reflect.ValueOf(value)
+	if value == nil || v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true
+}
+
+func findStrategy(strategies []pathStrategy, key string) (MergeStrategy, bool) {
+	for i := len(strategies) - 1; i >= 0; i-- {
+		if matchPath(strategies[i].path, key) {
+			return strategies[i].strategy, true
+		}
+	}
+	return MergeStrategy{}, false
+}
+
+func matchPath(pattern, key string) bool {
+	ps, ks := strings.Split(pattern, "."), strings.Split(key, ".")
+	if len(ps) != len(ks) {
+		return false
+	}
+	for i := range ps {
+		if ps[i] != "*" && ps[i] != ks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMergeDirective parses the merge directive of a document.
+func parseMergeDirective(directive interface{}) ([]pathStrategy, error) {
+	if directive == nil {
+		return nil, nil
+	}
+	m, ok := toStringMap(directive)
+	if !ok {
+		return nil, fmt.Errorf("%s :: should be a map of paths to merge strategies", mergeDirectiveKey)
+	}
+
+	var strategies []pathStrategy
+	for path, value := range m {
+		strategy, err := parseMergeStrategy(fmt.Sprint(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s :: %s :: %s", mergeDirectiveKey, path, err)
+		}
+		strategies = append(strategies, pathStrategy{path: strings.ToLower(path), strategy: strategy})
+	}
+
+	// The exact paths are found before the ones with wildcards.
+	sort.Slice(strategies, func(i, j int) bool {
+		wi, wj := strings.Count(strategies[i].path, "*"), strings.Count(strategies[j].path, "*")
+		if wi != wj {
+			return wi > wj
+		}
+		return strategies[i].path < strategies[j].path
+	})
+	return strategies, nil
+}
+
+func parseMergeStrategy(value string) (MergeStrategy, error) {
+	name, field := strings.TrimSpace(value), ""
+	if i := strings.Index(name, ":"); i >= 0 {
+		name, field = strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+	}
+
+	for _, m := range []MergeStrategy{MergeReplace, MergeAppend, MergePrepend, MergeUnion} {
+		if strings.EqualFold(name, m.name) && field == "" {
+			return m, nil
+		}
+	}
+	if strings.EqualFold(name, "merge-by-key") && field != "" {
+		return MergeByKey(field), nil
+	}
+	return MergeStrategy{}, fmt.Errorf("unsupported merge strategy: %s", value)
+}
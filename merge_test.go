@@ -0,0 +1,164 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitMergeSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitMergeSuite) TestSetMergeStrategy() {
+	for _, tc := range []struct {
+		strategy MergeStrategy
+		expected []string
+	}{
+		{MergeReplace, []string{"b", "c"}},
+		{MergeAppend, []string{"a", "b", "b", "c"}},
+		{MergePrepend, []string{"b", "c", "a", "b"}},
+		{MergeUnion, []string{"a", "b", "c"}},
+	} {
+		sm := NewFromContent("server:\n  plugins: [a, b]\n").
+			SetMergeStrategy("Server.Plugins", tc.strategy).
+			MergeContent("server:\n  plugins: [b, c]\n", "yaml")
+		u.Equal(nil, sm.Error)
+
+		plugins, err := sm.GetStringSlice("server.plugins")
+		u.Equal(nil, err)
+		u.Equal(tc.expected, plugins, tc.strategy.String())
+	}
+
+	sm := &Settings{Error: errors.New("error")}
+	u.Equal("settings.SetMergeStrategy :: error", fmt.Sprint(sm.SetMergeStrategy("a", MergeAppend).Error))
+}
+
+func (u unitMergeSuite) TestMergeByKey() {
+	sm := NewFromContent(testMergeUsers).
+		SetMergeStrategy("*.users", MergeByKey("name")).
+		MergeContent(`
+team:
+  users:
+    - name: admin
+      role: owner
+      contact:
+        phone: "123"
+    - name: guest
+`, "yaml")
+	u.Equal(nil, sm.Error)
+
+	users, err := sm.Get("team.users")
+	u.Equal(nil, err)
+	u.Equal([]interface{}{
+		map[string]interface{}{"name": "admin", "role": "owner", "contact": map[string]interface{}{"email": "admin@example.com", "phone": "123"}},
+		map[string]interface{}{"name": "dev", "role": "developer"},
+		map[string]interface{}{"name": "guest"},
+	}, normalizeTestValue(users))
+	u.Equal("merge-by-key:name", MergeByKey("name").String())
+}
+
+func (u unitMergeSuite) TestMergeDirective() {
+	initTestOk()
+
+	u.Equal(nil, ioutil.WriteFile(filepath.Join(testDirPath, "overlay.yaml"), []byte(`
+$merge:
+  team.users: merge-by-key:name
+  "*.plugins": prepend
+team:
+  users:
+    - name: dev
+      role: lead
+server:
+  plugins: [first]
+`), 0644))
+
+	sm := NewFromContent(testMergeUsers).
+		SetMergeStrategy("server.plugins", MergeAppend).
+		Merge(filepath.Join(testDirPath, "overlay.yaml"))
+	u.Equal(nil, sm.Error)
+
+	plugins, err := sm.GetStringSlice("server.plugins")
+	u.Equal(nil, err)
+	u.Equal([]string{"first", "metrics"}, plugins)
+
+	role, err := sm.Get("team.users")
+	u.Equal(nil, err)
+	u.Equal(map[string]interface{}{"name": "dev", "role": "lead"}, normalizeTestValue(role).([]interface{})[1])
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.NotContains(keys, "$merge.team.users")
+
+	u.Equal(nil, ioutil.WriteFile(filepath.Join(testDirPath, "overlay.yaml"), []byte("$merge:\n  team.users: shuffle\n"), 0644))
+	sm = NewFromContent(testMergeUsers).Merge(filepath.Join(testDirPath, "overlay.yaml"))
+	u.Contains(fmt.Sprint(sm.Error), "$merge :: team.users :: unsupported merge strategy: shuffle")
+
+	sm = NewFromContent("$merge: append\n")
+	u.Contains(fmt.Sprint(sm.Error), "$merge :: should be a map of paths to merge strategies")
+
+	resetTest()
+}
+
+func (u unitMergeSuite) TestParseMergeStrategy() {
+	for value, expected := range map[string]MergeStrategy{
+		"replace":            MergeReplace,
+		"Append":             MergeAppend,
+		" prepend ":          MergePrepend,
+		"union":              MergeUnion,
+		"merge-by-key: name": MergeByKey("name"),
+	} {
+		strategy, err := parseMergeStrategy(value)
+		u.Equal(nil, err)
+		u.Equal(expected, strategy)
+	}
+
+	for _, value := range []string{"", "merge-by-key", "append:name", "shuffle"} {
+		_, err := parseMergeStrategy(value)
+		u.Equal(fmt.Sprintf("unsupported merge strategy: %s", value), fmt.Sprint(err))
+	}
+}
+
+func TestMergeUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitMergeSuite))
+}
+
+// normalizeTestValue converts the maps of a value to map[string]interface{}.
+func normalizeTestValue(value interface{}) interface{} {
+	if m, ok := toStringMap(value); ok {
+		c := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			c[k] = normalizeTestValue(v)
+		}
+		return c
+	}
+	if items, ok := value.([]interface{}); ok {
+		c := make([]interface{}, len(items))
+		for i, v := range items {
+			c[i] = normalizeTestValue(v)
+		}
+		return c
+	}
+	return value
+}
+
+var (
+	testMergeUsers = `
+server:
+  plugins: [metrics]
+team:
+  users:
+    - name: admin
+      role: user
+      contact:
+        email: admin@example.com
+    - name: dev
+      role: developer
+`
+)
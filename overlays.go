@@ -0,0 +1,121 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// overlaySource is a base settings file and its optional overlays of the selected environment profiles.
+// The overlays are looked up again on every reload, so that the overlays created later are applied too.
+type overlaySource struct {
+	dir      string
+	base     string
+	profiles []string
+}
+
+// NewWithProfiles initializes settings from a base settings file of a directory and its overlays
+// of the given environment profiles, see MergeProfiles.
+func NewWithProfiles(dir, base string, profiles ...string) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeProfiles(dir, base, profiles, "NewWithProfiles")
+}
+
+// MergeProfiles merges initialized settings with a base settings file of a directory, e.g. "config.yaml",
+// and its overlays of the given environment profiles. The base file has to exist, the overlays are optional.
+//
+// The files are merged in the following order, the files merged later take precedence:
+//
+//	config.yaml
+//	config.<profile>.yaml        for each profile in the given order
+//	config.<profile>.local.yaml
+//
+// The applied overlays are reported by GetSettingsFileNames.
+func (s *Settings) MergeProfiles(dir, base string, profiles ...string) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeProfiles(dir, base, profiles, "MergeProfiles")
+}
+
+// ProfilesFromEnv returns the comma separated list of environment profiles of an environment variable,
+// e.g. "production,eu" of APP_ENV, to be passed to NewWithProfiles or MergeProfiles.
+func ProfilesFromEnv(name string) []string {
+	var profiles []string
+	for _, p := range strings.Split(os.Getenv(name), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+func (s *Settings) mergeProfiles(dir, base string, profiles []string, funcName string) *Settings {
+	if filepath.Ext(base) == "" || filepath.Base(base) != base {
+		return &Settings{Error: fmt.Errorf("settings.%s :: %s :: should be a file name with extension", funcName, base)}
+	}
+
+	var selected []string
+	for _, p := range profiles {
+		if p == "" {
+			continue
+		}
+		if strings.ContainsAny(p, `/\`) || p == "." || p == ".." {
+			return &Settings{Error: fmt.Errorf("settings.%s :: %s :: invalid profile", funcName, p)}
+		}
+		selected = append(selected, p)
+	}
+
+	src := &overlaySource{dir: dir, base: base, profiles: selected}
+	if err := s.addSource(src, 0); err != nil {
+		return &Settings{Error: fmt.Errorf("settings.%s :: %s", funcName, err)}
+	}
+	return s
+}
+
+func (o *overlaySource) Name() string {
+	return filepath.Join(o.dir, o.base)
+}
+
+func (o *overlaySource) Load(ctx context.Context) (map[string]interface{}, error) {
+	return loadDocuments(o)
+}
+
+func (o *overlaySource) Watch(ctx context.Context) (<-chan Event, error) {
+	return nil, nil
+}
+
+func (o *overlaySource) mergeInto(s *Settings) error {
+	if err := s.load(o.Name()).Error; err != nil {
+		return err
+	}
+	for _, file := range o.overlays() {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		if err := s.load(file).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overlays returns the overlay files of the selected profiles in the order they are merged.
+func (o *overlaySource) overlays() []string {
+	ext := filepath.Ext(o.base)
+	name := strings.TrimSuffix(o.base, ext)
+
+	var files []string
+	for _, p := range o.profiles {
+		files = append(files,
+			filepath.Join(o.dir, name+"."+p+ext),
+			filepath.Join(o.dir, name+"."+p+".local"+ext),
+		)
+	}
+	return files
+}
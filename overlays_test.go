@@ -0,0 +1,115 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitOverlaysSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitOverlaysSuite) TestNewWithProfiles() {
+	initTestOverlays(u)
+
+	sm := NewWithProfiles(testDirPath, "config.yaml", "production", "eu")
+	u.Equal(nil, sm.Error)
+
+	files, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{
+		filepath.Join(testDirPath, "config.yaml"),
+		filepath.Join(testDirPath, "config.production.yaml"),
+		filepath.Join(testDirPath, "config.production.local.yaml"),
+		filepath.Join(testDirPath, "config.eu.yaml"),
+	}, files)
+
+	for key, expected := range map[string]string{
+		"app.name":   "base",
+		"app.level":  "local",
+		"app.region": "eu",
+	} {
+		v, err := sm.GetString(key)
+		u.Equal(nil, err)
+		u.Equal(expected, v, key)
+	}
+
+	u.Equal(nil, ioutil.WriteFile(filepath.Join(testDirPath, "config.eu.local.yaml"), []byte("app:\n  region: eu-local\n"), 0644))
+	sm.Reload()
+
+	v, err := sm.GetString("app.region")
+	u.Equal(nil, err)
+	u.Equal("eu-local", v)
+
+	sm = NewWithProfiles(testDirPath, "config.yaml")
+	files, err = sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{filepath.Join(testDirPath, "config.yaml")}, files)
+
+	resetTest()
+}
+
+func (u unitOverlaysSuite) TestMergeProfiles() {
+	initTestOverlays(u)
+
+	sm := New(testYamlFilePAth).MergeProfiles(testDirPath, "config.yaml", "staging", "", "production")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("app.level")
+	u.Equal(nil, err)
+	u.Equal("local", v)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	sm = NewWithProfiles(testDirPath, "missing.yaml", "production")
+	u.Equal("settings.NewWithProfiles :: open settings/missing.yaml: no such file or directory", fmt.Sprint(sm.Error))
+
+	sm = New(testYamlFilePAth).MergeProfiles(testDirPath, "config", "production")
+	u.Equal("settings.MergeProfiles :: config :: should be a file name with extension", fmt.Sprint(sm.Error))
+
+	sm = New(testYamlFilePAth).MergeProfiles(testDirPath, "config.yaml", "../production")
+	u.Equal("settings.MergeProfiles :: ../production :: invalid profile", fmt.Sprint(sm.Error))
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("error", fmt.Sprint(sm.MergeProfiles(testDirPath, "config.yaml").Error))
+
+	resetTest()
+}
+
+func (u unitOverlaysSuite) TestProfilesFromEnv() {
+	u.Equal(nil, os.Setenv("SETTINGS_TEST_APP_ENV", " production, ,eu "))
+	defer func() {
+		_ = os.Unsetenv("SETTINGS_TEST_APP_ENV")
+	}()
+
+	u.Equal([]string{"production", "eu"}, ProfilesFromEnv("SETTINGS_TEST_APP_ENV"))
+	u.Equal([]string(nil), ProfilesFromEnv("SETTINGS_TEST_MISSING_ENV"))
+}
+
+func TestOverlaysUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitOverlaysSuite))
+}
+
+func initTestOverlays(u unitOverlaysSuite) {
+	initTestOk()
+
+	for file, content := range map[string]string{
+		"config.yaml":                  "app:\n  name: base\n  level: base\n  region: none\n",
+		"config.production.yaml":       "app:\n  level: production\n",
+		"config.production.local.yaml": "app:\n  level: local\n",
+		"config.eu.yaml":               "app:\n  region: eu\n",
+		"config.staging.yaml":          "app:\n  level: staging\n",
+	} {
+		u.Equal(nil, ioutil.WriteFile(filepath.Join(testDirPath, file), []byte(content), 0644))
+	}
+}
@@ -0,0 +1,98 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// override is a value set at runtime, which takes precedence over all sources.
+type override struct {
+	key   string
+	value interface{}
+}
+
+// Set overrides the value of a key at runtime, e.g. to switch a feature toggle.
+// Overrides take precedence over all sources, and they are kept by Reload, until they are removed
+// by Unset or ClearOverrides. Set is case-insensitive for a key.
+func (s *Settings) Set(key string, value interface{}) *Settings {
+	if s.Error != nil {
+		return &Settings{Error: fmt.Errorf("settings.Set :: %s", s.Error)}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	key = strings.ToLower(key)
+	s.overrides = append(removeOverrides(s.overrides, key), override{key: key, value: value})
+	s.Data.Set(key, value)
+	return s
+}
+
+// Unset removes the override of a key and of the keys under it, so the value of the sources applies again.
+func (s *Settings) Unset(key string) *Settings {
+	if s.Error != nil {
+		return &Settings{Error: fmt.Errorf("settings.Unset :: %s", s.Error)}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.setOverrides(removeOverrides(s.overrides, strings.ToLower(key)), "Unset")
+}
+
+// ClearOverrides removes all overrides, so the values of the sources apply again.
+func (s *Settings) ClearOverrides() *Settings {
+	if s.Error != nil {
+		return &Settings{Error: fmt.Errorf("settings.ClearOverrides :: %s", s.Error)}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.setOverrides(nil, "ClearOverrides")
+}
+
+// GetOverrides returns the keys and values set by Set.
+func (s *Settings) GetOverrides() (map[string]interface{}, error) {
+	if s.Error != nil {
+		return map[string]interface{}{}, fmt.Errorf("settings.GetOverrides :: %s", s.Error)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	overrides := make(map[string]interface{}, len(s.overrides))
+	for _, o := range s.overrides {
+		overrides[o.key] = o.value
+	}
+	return overrides, nil
+}
+
+// setOverrides replaces the overrides, and rebuilds the settings data without the removed ones.
+func (s *Settings) setOverrides(overrides []override, funcName string) *Settings {
+	previous := s.overrides
+	s.overrides = overrides
+
+	if err := s.rebuild(); err != nil {
+		s.overrides = previous
+		return &Settings{Error: fmt.Errorf("settings.%s :: %s", funcName, err)}
+	}
+	return s
+}
+
+func (s *Settings) applyOverrides() {
+	for _, o := range s.overrides {
+		s.Data.Set(o.key, o.value)
+	}
+}
+
+// removeOverrides returns the overrides without the ones of a key and of the keys under it.
+func removeOverrides(overrides []override, key string) []override {
+	var kept []override
+	for _, o := range overrides {
+		if o.key != key && !strings.HasPrefix(o.key, key+".") {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitOverridesSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitOverridesSuite) TestSet() {
+	initTestOk()
+
+	sm := New(testYamlFilePAth).
+		Set("Service.Name", "Overridden").
+		Set("feature.enabled", true)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Overridden", v)
+
+	isSet, err := sm.IsSet("feature.enabled")
+	u.Equal(nil, err)
+	u.Equal(true, isSet)
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.Contains(keys, "feature.enabled")
+
+	sm = sm.Merge(testYamlFilePAth)
+	u.Equal(nil, sm.Error)
+	sm.Reload()
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Overridden", v)
+
+	b, err := sm.GetBool("feature.enabled")
+	u.Equal(nil, err)
+	u.Equal(true, b)
+
+	overrides, err := sm.GetOverrides()
+	u.Equal(nil, err)
+	u.Equal(map[string]interface{}{"service.name": "Overridden", "feature.enabled": true}, overrides)
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("settings.Set :: error", fmt.Sprint(sm.Set("key", 1).Error))
+
+	_, err = sm.GetOverrides()
+	u.Equal("settings.GetOverrides :: error", fmt.Sprint(err))
+
+	resetTest()
+}
+
+func (u unitOverridesSuite) TestUnset() {
+	initTestOk()
+
+	sm := New(testYamlFilePAth).
+		Set("service.name", "Overridden").
+		Set("feature.enabled", true).
+		Set("feature.list", []string{"a"})
+	u.Equal(nil, sm.Error)
+
+	sm = sm.Unset("service.name")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	sm = sm.Unset("feature")
+	u.Equal(nil, sm.Error)
+
+	isSet, err := sm.IsSet("feature.enabled")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.NotContains(keys, "feature.list")
+
+	sm = sm.Set("service.name", "Overridden").ClearOverrides()
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	overrides, err := sm.GetOverrides()
+	u.Equal(nil, err)
+	u.Empty(overrides)
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("settings.Unset :: error", fmt.Sprint(sm.Unset("key").Error))
+	u.Equal("settings.ClearOverrides :: error", fmt.Sprint(sm.ClearOverrides().Error))
+
+	resetTest()
+}
+
+func TestOverridesUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitOverridesSuite))
+}
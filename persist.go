@@ -0,0 +1,163 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultFilePerm os.FileMode = 0644
+
+// WriteTo writes the effective settings, including the overrides, to a file of the given format,
+// e.g. "json" or "yaml". An empty format means that it is chosen by the extension of the file.
+// The file is replaced atomically, and its permissions are kept.
+func (s *Settings) WriteTo(path, format string) error {
+	if s.Error != nil {
+		return fmt.Errorf("settings.WriteTo :: %s", s.Error)
+	}
+	if err := s.writeSettings(path, format, s.Data.AllSettings()); err != nil {
+		return fmt.Errorf("settings.WriteTo :: %s", err)
+	}
+	return nil
+}
+
+// Save writes the effective settings, including the overrides, back to the settings file they were loaded from.
+// A change written by Save does not trigger a reload by AutoReload.
+//
+// Save is allowed only for settings loaded from a single settings file, without includes, overlays
+// or other sources, so that their values, e.g. the secrets of Vault, are not copied into the file.
+// A sub tree returned by SubTree cannot be saved either, it would replace the whole file.
+// Use WriteTo or SaveOverrides for the other settings.
+func (s *Settings) Save() error {
+	if s.Error != nil {
+		return fmt.Errorf("settings.Save :: %s", s.Error)
+	}
+
+	s.mux.Lock()
+	file, err := s.saveFile()
+	s.mux.Unlock()
+	if err != nil {
+		return fmt.Errorf("settings.Save :: %s", err)
+	}
+
+	if isArchive(file) || isGzip(file) {
+		return fmt.Errorf("settings.Save :: %s :: cannot write compressed file", file)
+	}
+	if err := s.writeSettings(file, "", s.Data.AllSettings()); err != nil {
+		return fmt.Errorf("settings.Save :: %s", err)
+	}
+	return nil
+}
+
+// saveFile returns the settings file written by Save.
+func (s *Settings) saveFile() (string, error) {
+	if len(s.fileNames) == 0 {
+		return "", fmt.Errorf("cannot find settings file")
+	}
+	if s.subTree != "" {
+		return "", fmt.Errorf("%s :: cannot save a sub tree, use WriteTo or SaveOverrides", s.subTree)
+	}
+	if len(s.fileNames) > 1 || len(s.layers) != 1 {
+		return "", fmt.Errorf("cannot save settings merged from several sources, use WriteTo or SaveOverrides")
+	}
+	if _, ok := s.layers[0].source.(*fileSource); !ok {
+		return "", fmt.Errorf("cannot save settings merged from several sources, use WriteTo or SaveOverrides")
+	}
+	return s.fileNames[0], nil
+}
+
+// SaveOverrides writes only the overrides set by Set to a file, e.g. to an overlay merged after the others.
+// The format is chosen by the extension of the file.
+func (s *Settings) SaveOverrides(path string) error {
+	if s.Error != nil {
+		return fmt.Errorf("settings.SaveOverrides :: %s", s.Error)
+	}
+
+	s.mux.Lock()
+	m := map[string]interface{}{}
+	for _, o := range s.overrides {
+		setNested(m, strings.Split(o.key, "."), o.value)
+	}
+	s.mux.Unlock()
+
+	if err := s.writeSettings(path, "", m); err != nil {
+		return fmt.Errorf("settings.SaveOverrides :: %s", err)
+	}
+	return nil
+}
+
+func (s *Settings) writeSettings(path, formatName string, m map[string]interface{}) error {
+	f, ok := formats.byName(formatName)
+	if formatName == "" {
+		f, ok = formats.byExtension(filepath.Ext(path))
+	}
+	if !ok {
+		if formatName == "" {
+			return fmt.Errorf("Unsupported Config Type %q", getExtensionByFileName(path))
+		}
+		return fmt.Errorf("unsupported content type: %s", formatName)
+	}
+
+	b, err := f.encode(m)
+	if err != nil {
+		return err
+	}
+
+	target, perm, err := resolveFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	if s.written == nil {
+		s.written = map[string][]byte{}
+	}
+	s.written[filepath.Clean(path)] = b
+	s.written[filepath.Clean(target)] = b
+	s.mux.Unlock()
+
+	return writeFileAtomic(target, b, perm)
+}
+
+// resolveFile returns the file to be replaced and its permissions.
+// A symbolic link is kept, the file it points to is replaced.
+func resolveFile(path string) (string, os.FileMode, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		target = path
+	} else if err != nil {
+		return "", 0, err
+	}
+
+	perm := defaultFilePerm
+	if fi, err := os.Stat(target); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	return target, perm, nil
+}
+
+// isOwnWrite reports whether a settings file holds the content written by the settings themselves,
+// so that AutoReload does not reload it again.
+func (s *Settings) isOwnWrite(file string) bool {
+	file = filepath.Clean(file)
+
+	s.mux.Lock()
+	written, ok := s.written[file]
+	s.mux.Unlock()
+
+	if !ok {
+		return false
+	}
+	b, err := ioutil.ReadFile(file)
+	if err == nil && bytes.Equal(b, written) {
+		return true
+	}
+
+	s.mux.Lock()
+	delete(s.written, file)
+	s.mux.Unlock()
+	return false
+}
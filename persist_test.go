@@ -0,0 +1,157 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitPersistSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitPersistSuite) TestWriteTo() {
+	initTestOk()
+
+	sm := New(testYamlFilePAth).Set("service.name", "Written")
+	u.Equal(nil, sm.Error)
+
+	file := filepath.Join(testDirPath, "written.json")
+	u.Equal(nil, sm.WriteTo(file, ""))
+
+	written := New(file)
+	u.Equal(nil, written.Error)
+
+	v, err := written.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Written", v)
+
+	v, err = written.GetString("email.server.address")
+	u.Equal(nil, err)
+	u.Equal("smtp.gmail.com", v)
+
+	file = filepath.Join(testDirPath, "written.conf")
+	u.Equal(nil, sm.WriteTo(file, "yaml"))
+
+	written = NewFromContentAs(readTestFile(u, file), "yaml")
+	u.Equal(nil, written.Error)
+
+	v, err = written.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Written", v)
+
+	u.Equal(`settings.WriteTo :: Unsupported Config Type "conf"`, fmt.Sprint(sm.WriteTo(file, "")))
+	u.Equal("settings.WriteTo :: unsupported content type: ini", fmt.Sprint(sm.WriteTo(file, "ini")))
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("settings.WriteTo :: error", fmt.Sprint(sm.WriteTo(file, "")))
+
+	resetTest()
+}
+
+func (u unitPersistSuite) TestSave() {
+	initTestOk()
+	u.Equal(nil, os.Chmod(testYamlFilePAth, 0640))
+
+	sm := New(testYamlFilePAth).Set("service.name", "Saved")
+	u.Equal(nil, sm.Error)
+	u.Equal(nil, sm.Save())
+
+	fi, err := os.Stat(testYamlFilePAth)
+	u.Equal(nil, err)
+	u.Equal(os.FileMode(0640), fi.Mode().Perm())
+
+	u.Equal(true, sm.isOwnWrite(testYamlFilePAth))
+
+	sm = New(testYamlFilePAth)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Saved", v)
+
+	u.Equal(nil, ioutil.WriteFile(testYamlFilePAth, []byte(testYamlContent), 0640))
+	u.Equal(false, sm.isOwnWrite(testYamlFilePAth))
+
+	link := filepath.Join(testDirPath, "link.yaml")
+	u.Equal(nil, os.Symlink(filepath.Base(testYamlFilePAth), link))
+
+	sm = New(link).Set("service.name", "Linked")
+	u.Equal(nil, sm.Save())
+
+	fi, err = os.Lstat(link)
+	u.Equal(nil, err)
+	u.Equal(os.ModeSymlink, fi.Mode()&os.ModeSymlink)
+
+	v, err = New(testYamlFilePAth).GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Linked", v)
+
+	u.Equal("settings.Save :: cannot find settings file", fmt.Sprint(NewFromContent(testYamlContent).Save()))
+
+	sub := New(testYamlFilePAth).SubTree("email").SubTree("server")
+	u.Equal(nil, sub.Error)
+	u.Equal("settings.Save :: email.server :: cannot save a sub tree, use WriteTo or SaveOverrides", fmt.Sprint(sub.Save()))
+
+	v, err = New(testYamlFilePAth).GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Linked", v)
+
+	merged := New(testYamlFilePAth).MergeContent(`service: {name: Merged}`, "yaml")
+	u.Equal("settings.Save :: cannot save settings merged from several sources, use WriteTo or SaveOverrides", fmt.Sprint(merged.Save()))
+
+	u.Equal(nil, ioutil.WriteFile(filepath.Join(testDirPath, "main.yaml"), []byte("$include: test.yaml\nport: 1\n"), 0644))
+	included := New(filepath.Join(testDirPath, "main.yaml"))
+	u.Equal(nil, included.Error)
+	u.Equal("settings.Save :: cannot save settings merged from several sources, use WriteTo or SaveOverrides", fmt.Sprint(included.Save()))
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("settings.Save :: error", fmt.Sprint(sm.Save()))
+
+	resetTest()
+}
+
+func (u unitPersistSuite) TestSaveOverrides() {
+	initTestOk()
+
+	sm := New(testYamlFilePAth).
+		Set("service.name", "Overlay").
+		Set("feature.enabled", true)
+	u.Equal(nil, sm.Error)
+
+	file := filepath.Join(testDirPath, "overrides.yaml")
+	u.Equal(nil, sm.SaveOverrides(file))
+
+	overlay := New(file)
+	u.Equal(nil, overlay.Error)
+
+	keys, err := overlay.GetAllKeys()
+	u.Equal(nil, err)
+	u.ElementsMatch([]string{"service.name", "feature.enabled"}, keys)
+
+	fi, err := os.Stat(file)
+	u.Equal(nil, err)
+	u.Equal(defaultFilePerm, fi.Mode().Perm())
+
+	sm = &Settings{Error: errors.New("error")}
+	u.Equal("settings.SaveOverrides :: error", fmt.Sprint(sm.SaveOverrides(file)))
+
+	resetTest()
+}
+
+func TestPersistUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitPersistSuite))
+}
+
+func readTestFile(u unitPersistSuite, file string) string {
+	b, err := ioutil.ReadFile(file)
+	u.Equal(nil, err)
+	return string(b)
+}
@@ -0,0 +1,79 @@
+package settings
+
+import (
+	"fmt"
+)
+
+// profileKey marks a document of a multi-document stream as a named profile.
+const profileKey = "profile"
+
+// UseProfile selects a named profile of the multi-document YAML streams and re-reads the settings data.
+//
+// A document carrying a top-level marker, e.g. "profile: production", is merged only,
+// when its profile is selected. Unmarked documents are always merged.
+// All documents are merged in the order they appear. An empty name deselects the profile.
+func (s *Settings) UseProfile(name string) *Settings {
+	if s.Error != nil {
+		return &Settings{Error: fmt.Errorf("settings.UseProfile :: %s", s.Error)}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	previous := s.profile
+	s.profile = name
+
+	if err := s.rebuild(); err != nil {
+		s.profile = previous
+		return &Settings{Error: fmt.Errorf("settings.UseProfile :: %s", err)}
+	}
+
+	if name != "" && !containsString(s.profiles, name) {
+		s.profile = previous
+		_ = s.rebuild()
+		return &Settings{Error: fmt.Errorf("settings.UseProfile :: %s :: cannot find profile", name)}
+	}
+	return s
+}
+
+// GetProfiles returns the name of all profiles found in the multi-document YAML streams.
+func (s *Settings) GetProfiles() ([]string, error) {
+	if s.Error != nil {
+		return nil, fmt.Errorf("settings.GetProfiles :: %s", s.Error)
+	}
+	return s.profiles, nil
+}
+
+func (s *Settings) mergeDocuments(docs []map[string]interface{}) error {
+	for i, doc := range docs {
+		s.merging.document = i
+		if len(docs) > 1 {
+			if name, ok := doc[profileKey].(string); ok {
+				s.profiles = makeUniqueSlice(append(s.profiles, name))
+				if name != s.profile {
+					continue
+				}
+				delete(doc, profileKey)
+			}
+		}
+		if directive, ok := doc[includeKey]; ok {
+			delete(doc, includeKey)
+			if err := s.mergeIncludes(directive); err != nil {
+				return err
+			}
+		}
+		if err := s.mergeConfigMap(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(slice []string, s string) bool {
+	for _, elem := range slice {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
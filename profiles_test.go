@@ -0,0 +1,126 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitProfilesSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitProfilesSuite) TestMultiDocumentStream() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testYamlFileOtherPAth, []byte(testYamlProfilesContent), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testYamlFileOtherPAth)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("localhost", v)
+
+	v, err = sm.Get("server.port")
+	u.Equal(nil, err)
+	u.Equal(9090, v)
+
+	isSet, err := sm.IsSet("profile")
+	u.Equal(nil, err)
+	u.Equal(false, isSet)
+
+	profiles, err := sm.GetProfiles()
+	u.Equal(nil, err)
+	u.Equal([]string{"production", "test"}, profiles)
+
+	resetTest()
+}
+
+func (u unitProfilesSuite) TestUseProfile() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testYamlFileOtherPAth, []byte(testYamlProfilesContent), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testYamlFileOtherPAth).UseProfile("production")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("example.com", v)
+
+	v, err = sm.Get("server.port")
+	u.Equal(nil, err)
+	u.Equal(9090, v)
+
+	sm.Reload()
+
+	v, err = sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("example.com", v)
+
+	sm = sm.UseProfile("test")
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("test.example.com", v)
+
+	err = sm.UseProfile("staging").Error
+	u.Equal("settings.UseProfile :: staging :: cannot find profile", fmt.Sprint(err))
+
+	v, err = sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("test.example.com", v)
+
+	sm = sm.UseProfile("")
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("localhost", v)
+
+	resetTest()
+}
+
+func (u unitProfilesSuite) TestUseProfileFromContent() {
+	sm := NewFromContentAs(testYamlProfilesContent, "yaml").UseProfile("production")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("server.host")
+	u.Equal(nil, err)
+	u.Equal("example.com", v)
+
+	err = NewFromContent(testBadYamlContent).UseProfile("production").Error
+	u.Equal("settings.UseProfile :: settings.NewFromContent :: unsupported content type", fmt.Sprint(err))
+}
+
+func TestProfilesUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitProfilesSuite))
+}
+
+var (
+	testYamlProfilesContent = `
+server:
+  host: localhost
+  port: 8080
+---
+profile: production
+server:
+  host: example.com
+---
+profile: test
+server:
+  host: test.example.com
+---
+server:
+  port: 9090
+`
+)
@@ -0,0 +1,305 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// overrideSource is the name of the source of the values set by Set.
+const overrideSource = "override"
+
+// Origin describes where a value of the settings came from.
+type Origin struct {
+	// Source is the name of the source, e.g. the file or directory passed to New or Merge.
+	Source string
+
+	// File is the file holding the value, it is empty for the sources not read from files.
+	File string
+
+	// Line and Column are the position of the key in the YAML or JSON file, they are 0 when unknown.
+	Line   int
+	Column int
+
+	// Layer is the index of the source in the order the sources are merged.
+	// The overrides set by Set come after all sources.
+	Layer int
+
+	// Value is the value provided by the source.
+	Value interface{}
+
+	// Deleted reports, that the source deleted the key by a delete marker.
+	Deleted bool
+}
+
+// KeyOrigin is the origin of the effective value of a key, and the values shadowed by it.
+type KeyOrigin struct {
+	Origin
+
+	// Shadowed are the values of the lower layers, the most recent one first.
+	Shadowed []Origin
+}
+
+// AnnotatedValue is a value of the settings together with its origin.
+type AnnotatedValue struct {
+	Value  interface{}
+	Origin Origin
+}
+
+// filePosition is the position of a key in a settings file.
+type filePosition struct {
+	line   int
+	column int
+}
+
+// mergeContext describes the source being merged, so that the merged values can be traced back to it.
+type mergeContext struct {
+	origin    Origin
+	positions []map[string]filePosition
+	document  int
+	include   *includeContext
+}
+
+// Origin returns the source, which provided the effective value of a key, and the values shadowed by it.
+// A key deleted by a delete marker of a merged file is reported with the Deleted flag.
+// The values of a map have their own origins, see the keys under it or GetAllSettingsWithOrigin.
+func (s *Settings) Origin(key string) (KeyOrigin, error) {
+	if s.Error != nil {
+		return KeyOrigin{}, fmt.Errorf("settings.Origin :: %s", s.Error)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	stack := s.originsOf(strings.ToLower(key))
+	if len(stack) == 0 {
+		if sub, ok := toStringMap(s.Data.Get(key)); ok && len(sub) > 0 {
+			return KeyOrigin{}, fmt.Errorf("settings.Origin :: %s :: is a map, its keys have their own origins", key)
+		}
+		return KeyOrigin{}, fmt.Errorf("settings.Origin :: %s :: cannot find value in configuration", key)
+	}
+
+	o := KeyOrigin{Origin: stack[len(stack)-1]}
+	for i := len(stack) - 2; i >= 0; i-- {
+		o.Shadowed = append(o.Shadowed, stack[i])
+	}
+	return o, nil
+}
+
+// GetAllSettingsWithOrigin returns all settings like GetAllSettings,
+// but every leaf value is an AnnotatedValue carrying the origin of the value.
+func (s *Settings) GetAllSettingsWithOrigin() (map[string]interface{}, error) {
+	if s.Error != nil {
+		return map[string]interface{}{}, fmt.Errorf("settings.GetAllSettingsWithOrigin :: %s", s.Error)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.annotate(s.Data.AllSettings(), nil), nil
+}
+
+func (s *Settings) annotate(m map[string]interface{}, path []string) map[string]interface{} {
+	annotated := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		p := append(path[:len(path):len(path)], k)
+		if sub, ok := toStringMap(v); ok && len(sub) > 0 {
+			annotated[k] = s.annotate(sub, p)
+			continue
+		}
+
+		a := AnnotatedValue{Value: v}
+		if stack := s.originsOf(strings.Join(p, ".")); len(stack) > 0 {
+			a.Origin = stack[len(stack)-1]
+		}
+		annotated[k] = a
+	}
+	return annotated
+}
+
+// originsOf returns the origins of a key, including the overrides, the effective one last.
+func (s *Settings) originsOf(key string) []Origin {
+	stack := s.origins[key][:len(s.origins[key]):len(s.origins[key])]
+	for _, o := range s.overrides {
+		if o.key == key || strings.HasPrefix(key, o.key+".") {
+			stack = append(stack, Origin{Source: overrideSource, Layer: len(s.layers), Value: s.Data.Get(key)})
+		}
+	}
+	return stack
+}
+
+// recordOrigins records the origin of the leaf values of a document being merged.
+func (s *Settings) recordOrigins(value interface{}, path []string) {
+	if m, ok := toStringMap(value); ok {
+		for k, v := range m {
+			s.recordOrigins(v, append(path[:len(path):len(path)], strings.ToLower(k)))
+		}
+		return
+	}
+
+	key := strings.Join(path, ".")
+	o := s.origin(key)
+	o.Value = value
+	s.addOrigin(key, o)
+}
+
+// recordDeletion records the deletion of a key and of the keys under it.
+func (s *Settings) recordDeletion(path []string) {
+	key := strings.Join(path, ".")
+	o := s.origin(key)
+	o.Deleted = true
+
+	if _, ok := s.origins[key]; !ok && key != "" {
+		s.addOrigin(key, o)
+	}
+	for k, stack := range s.origins {
+		if k == key || key == "" || strings.HasPrefix(k, key+".") {
+			if !stack[len(stack)-1].Deleted {
+				s.addOrigin(k, o)
+			}
+		}
+	}
+}
+
+func (s *Settings) addOrigin(key string, o Origin) {
+	if s.origins == nil {
+		s.origins = map[string][]Origin{}
+	}
+	s.origins[key] = append(s.origins[key], o)
+}
+
+// origin returns the origin of a key of the document being merged.
+func (s *Settings) origin(key string) Origin {
+	o := s.merging.origin
+	if s.merging.document < len(s.merging.positions) {
+		if p, ok := s.merging.positions[s.merging.document][key]; ok {
+			o.Line, o.Column = p.line, p.column
+		}
+	}
+	return o
+}
+
+// subOrigins returns the origins of the keys under a prefix, without the prefix.
+func subOrigins(origins map[string][]Origin, prefix string) map[string][]Origin {
+	sub := map[string][]Origin{}
+	for k, stack := range origins {
+		if strings.HasPrefix(k, prefix+".") {
+			sub[strings.TrimPrefix(k, prefix+".")] = stack
+		}
+	}
+	return sub
+}
+
+// yamlPositions returns the positions of the keys of each document of a YAML stream.
+func yamlPositions(content []byte) []map[string]filePosition {
+	var positions []map[string]filePosition
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		p := map[string]filePosition{}
+		collectYAMLPositions(doc.Content[0], nil, p)
+		positions = append(positions, p)
+	}
+	return positions
+}
+
+func collectYAMLPositions(n *yaml.Node, path []string, positions map[string]filePosition) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Value == "<<" {
+			continue
+		}
+		p := append(path[:len(path):len(path)], strings.ToLower(k.Value))
+		positions[strings.Join(p, ".")] = filePosition{line: k.Line, column: k.Column}
+		collectYAMLPositions(v, p, positions)
+	}
+}
+
+// jsonPositions returns the positions of the keys of a JSON document.
+func jsonPositions(content []byte) []map[string]filePosition {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	positions := map[string]filePosition{}
+	if err := collectJSONPositions(dec, content, nil, positions); err != nil {
+		return nil
+	}
+	return []map[string]filePosition{positions}
+}
+
+// collectJSONPositions reads the next value of a JSON document, and collects the positions of the keys of its objects.
+// The keys of the objects in arrays are not collected, like the ones of the YAML sequences.
+func collectJSONPositions(dec *json.Decoder, content []byte, path []string, positions map[string]filePosition) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		for dec.More() {
+			start := skipJSONSeparators(content, dec.InputOffset())
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			k, _ := t.(string)
+
+			p := append(path[:len(path):len(path)], strings.ToLower(k))
+			positions[strings.Join(p, ".")] = offsetPosition(content, start)
+			if err := collectJSONPositions(dec, content, p, positions); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			if err := collectJSONPositions(dec, content, nil, map[string]filePosition{}); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// skipJSONSeparators returns the offset of the next token of a JSON document after an offset.
+func skipJSONSeparators(content []byte, offset int64) int {
+	i := int(offset)
+	for i < len(content) && strings.IndexByte(" \t\r\n,:", content[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// offsetPosition returns the line and column of an offset of a content, both starting from 1.
+func offsetPosition(content []byte, offset int) filePosition {
+	before := content[:offset]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return filePosition{
+		line:   bytes.Count(before, []byte{'\n'}) + 1,
+		column: utf8.RuneCount(before[lineStart:]) + 1,
+	}
+}
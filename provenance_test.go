@@ -0,0 +1,174 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitProvenanceSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitProvenanceSuite) TestOrigin() {
+	initTestOk()
+
+	dir := filepath.Join(testDirPath, "conf.d")
+	base := filepath.Join(dir, "1-base.yaml")
+	local := filepath.Join(dir, "2-local.yaml")
+	u.Equal(nil, os.Mkdir(dir, os.ModePerm))
+	u.Equal(nil, ioutil.WriteFile(base, []byte("db:\n  host: db.example.com\n  port: 5432\n"), 0644))
+	u.Equal(nil, ioutil.WriteFile(local, []byte("# local settings\ndb:\n  host: localhost\n"), 0644))
+
+	sm := New(dir).MergeContent(`{"db": {"port": 6543}}`, "json")
+	u.Equal(nil, sm.Error)
+
+	o, err := sm.Origin("DB.Host")
+	u.Equal(nil, err)
+	u.Equal(Origin{Source: dir, File: local, Line: 3, Column: 3, Layer: 0, Value: "localhost"}, o.Origin)
+	u.Equal([]Origin{{Source: dir, File: base, Line: 2, Column: 3, Layer: 0, Value: "db.example.com"}}, o.Shadowed)
+
+	o, err = sm.Origin("db.port")
+	u.Equal(nil, err)
+	u.Equal(Origin{Source: "json", Line: 1, Column: 9, Layer: 1, Value: float64(6543)}, o.Origin)
+	u.Equal(1, len(o.Shadowed))
+
+	sm.Set("db.port", 7000)
+	o, err = sm.Origin("db.port")
+	u.Equal(nil, err)
+	u.Equal(Origin{Source: overrideSource, Layer: 2, Value: 7000}, o.Origin)
+	u.Equal(2, len(o.Shadowed))
+
+	sm.Reload()
+	o, err = sm.Origin("db.host")
+	u.Equal(nil, err)
+	u.Equal(local, o.File)
+	u.Equal(1, len(o.Shadowed))
+
+	_, err = sm.Origin("db.missing")
+	u.Equal("settings.Origin :: db.missing :: cannot find value in configuration", fmt.Sprint(err))
+
+	set, err := sm.IsSet("db")
+	u.Equal(nil, err)
+	u.Equal(true, set)
+	_, err = sm.Origin("db")
+	u.Equal("settings.Origin :: db :: is a map, its keys have their own origins", fmt.Sprint(err))
+
+	_, err = (&Settings{Error: errors.New("error")}).Origin("db.host")
+	u.Equal("settings.Origin :: error", fmt.Sprint(err))
+
+	resetTest()
+}
+
+func (u unitProvenanceSuite) TestOriginOfDeletedKey() {
+	sm := NewFromContent(testYamlContent).
+		MergeContent("server:\n  timeout: !unset\n", "yaml")
+	u.Equal(nil, sm.Error)
+
+	o, err := sm.Origin("server.timeout.read")
+	u.Equal(nil, err)
+	u.Equal(Origin{Source: "yaml", Line: 2, Column: 3, Layer: 1, Deleted: true}, o.Origin)
+	u.Equal(5, o.Shadowed[0].Value)
+
+	o, err = sm.Origin("server.timeout")
+	u.Equal(nil, err)
+	u.Equal(true, o.Deleted)
+	u.Equal(0, len(o.Shadowed))
+}
+
+func (u unitProvenanceSuite) TestOriginOfProfilesAndArchives() {
+	sm := NewFromContentAs("a: 1\n---\nprofile: dev\na: 2\n", "yaml").UseProfile("dev")
+	u.Equal(nil, sm.Error)
+
+	o, err := sm.Origin("a")
+	u.Equal(nil, err)
+	u.Equal(Origin{Source: "yaml", Line: 4, Column: 1, Value: 2}, o.Origin)
+	u.Equal(Origin{Source: "yaml", Line: 1, Column: 1, Value: 1}, o.Shadowed[0])
+
+	initTestOk()
+
+	archive := filepath.Join(testDirPath, "settings.zip")
+	u.Equal(nil, ioutil.WriteFile(archive, zipHelper(unitArchivesSuite{u.Suite}, []archiveMember{{name: "app.yaml", content: []byte("a: 3\n")}}), 0644))
+
+	o, err = New(archive).Origin("a")
+	u.Equal(nil, err)
+	u.Equal(archive+"/app.yaml", o.File)
+	u.Equal(1, o.Line)
+
+	resetTest()
+}
+
+func (u unitProvenanceSuite) TestOriginOfJSON() {
+	initTestOk()
+
+	file := filepath.Join(testDirPath, "app.json")
+	u.Equal(nil, ioutil.WriteFile(file, []byte(`{
+  "servers": [{"name": "a"}, {"name": "b"}],
+  "DB": {
+    "host": "db.example.com",
+	"ports": [5432, 5433], "name": "héllo", "user": "app"
+  }
+}
+`), 0644))
+
+	sm := New(file)
+	u.Equal(nil, sm.Error)
+
+	o, err := sm.Origin("db.host")
+	u.Equal(nil, err)
+	u.Equal(Origin{Source: file, File: file, Line: 4, Column: 5, Value: "db.example.com"}, o.Origin)
+
+	o, err = sm.Origin("db.ports")
+	u.Equal(nil, err)
+	u.Equal(5, o.Line)
+	u.Equal(2, o.Column)
+
+	o, err = sm.Origin("db.user")
+	u.Equal(nil, err)
+	u.Equal(5, o.Line)
+	u.Equal(42, o.Column)
+
+	o, err = sm.Origin("servers")
+	u.Equal(nil, err)
+	u.Equal(2, o.Line)
+	u.Equal(3, o.Column)
+
+	u.Equal([]map[string]filePosition(nil), jsonPositions([]byte(`{"a": [1, 2}`)))
+
+	resetTest()
+}
+
+func (u unitProvenanceSuite) TestGetAllSettingsWithOrigin() {
+	sm := NewFromContentAs("service:\n  name: Example\n  tags: [a, b]\n", "yaml").
+		Set("service.port", 8080)
+	u.Equal(nil, sm.Error)
+
+	all, err := sm.GetAllSettingsWithOrigin()
+	u.Equal(nil, err)
+	u.Equal(map[string]interface{}{
+		"service": map[string]interface{}{
+			"name": AnnotatedValue{Value: "Example", Origin: Origin{Source: "yaml", Line: 2, Column: 3, Value: "Example"}},
+			"tags": AnnotatedValue{Value: []interface{}{"a", "b"}, Origin: Origin{Source: "yaml", Line: 3, Column: 3, Value: []interface{}{"a", "b"}}},
+			"port": AnnotatedValue{Value: 8080, Origin: Origin{Source: overrideSource, Layer: 1, Value: 8080}},
+		},
+	}, all)
+
+	sub := NewFromContentAs("service:\n  name: Example\n", "yaml").SubTree("service")
+	o, err := sub.Origin("name")
+	u.Equal(nil, err)
+	u.Equal(2, o.Line)
+
+	_, err = (&Settings{Error: errors.New("error")}).GetAllSettingsWithOrigin()
+	u.Equal("settings.GetAllSettingsWithOrigin :: error", fmt.Sprint(err))
+}
+
+func TestProvenanceUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitProvenanceSuite))
+}
@@ -0,0 +1,241 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultURLTimeout  = 10 * time.Second
+	defaultURLInterval = 30 * time.Second
+	defaultURLBackoff  = 100 * time.Millisecond
+)
+
+// remoteSource is a built-in source, which is loaded and watched by itself instead of being read as a settings file.
+// It is added to the settings as a Source by remoteAdapter.
+type remoteSource interface {
+	name() string
+	load() (map[string]interface{}, error)
+	watch(stop <-chan struct{}, reload func(), report func(error))
+}
+
+// URLOptions configures a remote HTTP(S) settings source.
+type URLOptions struct {
+	// ContentType is the type of the content, e.g. "json" or "yaml".
+	// If empty, it is chosen by the Content-Type header or by the extension of the URL.
+	ContentType string
+
+	// Header is sent with every request, e.g. for authorization.
+	Header http.Header
+
+	// Timeout of a single request, defaults to 10 seconds.
+	Timeout time.Duration
+
+	// Interval of polling by AutoReload, defaults to 30 seconds.
+	Interval time.Duration
+
+	// Retries is the number of retries after a failed request.
+	// Backoff is the delay before the first retry, which is doubled by every further retry.
+	// It defaults to 100 milliseconds.
+	Retries int
+	Backoff time.Duration
+
+	// Client is used to send the requests, defaults to a new http.Client with the given Timeout.
+	Client *http.Client
+
+	// Cache stores the last successful payload, which is loaded when the source cannot be reached.
+	Cache CacheOptions
+}
+
+type urlSource struct {
+	url  string
+	opts URLOptions
+
+	mux          sync.Mutex
+	etag         string
+	lastModified string
+	content      []byte
+	contentType  string
+}
+
+// NewFromURL initializes settings from a remote HTTP(S) endpoint serving a json, yaml or xml content.
+func NewFromURL(rawURL string, opts URLOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeURL(rawURL, opts, "NewFromURL")
+}
+
+// MergeURL merges initialized settings with the content of a remote HTTP(S) endpoint.
+// AutoReload polls the endpoint with conditional requests, a not modified response does not trigger a reload.
+func (s *Settings) MergeURL(rawURL string, opts URLOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeURL(rawURL, opts, "MergeURL")
+}
+
+func (s *Settings) mergeURL(rawURL string, opts URLOptions, funcName string) *Settings {
+	return s.mergeRemote(withCache(newURLSource(rawURL, opts), opts.Cache), funcName)
+}
+
+func (s *Settings) mergeRemote(src remoteSource, funcName string) *Settings {
+	if err := s.addSource(remoteAdapter{remote: src}, 0); err != nil {
+		return &Settings{Error: fmt.Errorf("settings.%s :: %s", funcName, err)}
+	}
+	return s
+}
+
+func newURLSource(rawURL string, opts URLOptions) *urlSource {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultURLTimeout
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultURLInterval
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultURLBackoff
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+	return &urlSource{url: rawURL, opts: opts}
+}
+
+func (u *urlSource) name() string {
+	return u.url
+}
+
+func (u *urlSource) load() (map[string]interface{}, error) {
+	if _, err := u.fetch(); err != nil {
+		return nil, err
+	}
+
+	u.mux.Lock()
+	content, contentType := u.content, u.contentType
+	u.mux.Unlock()
+
+	f, ok := u.format(content, contentType)
+	if !ok {
+		return nil, fmt.Errorf("Get %q: unsupported content type", u.url)
+	}
+	return f.decode(content)
+}
+
+func (u *urlSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	ticker := time.NewTicker(u.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := u.fetch()
+			if err != nil {
+				report(err)
+			} else if changed {
+				reload()
+			}
+		}
+	}
+}
+
+// fetch requests the content with conditional headers, and reports whether it has changed.
+// Failed requests are retried with an exponential backoff.
+func (u *urlSource) fetch() (changed bool, err error) {
+	backoff := u.opts.Backoff
+
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		changed, retry, err = u.request()
+		if err == nil || !retry || attempt >= u.opts.Retries {
+			return changed, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (u *urlSource) request() (changed, retry bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, u.url, nil)
+	if err != nil {
+		return false, false, err
+	}
+	for key, values := range u.opts.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	u.mux.Lock()
+	if u.etag != "" {
+		req.Header.Set("If-None-Match", u.etag)
+	}
+	if u.lastModified != "" {
+		req.Header.Set("If-Modified-Since", u.lastModified)
+	}
+	u.mux.Unlock()
+
+	resp, err := u.opts.Client.Do(req)
+	if err != nil {
+		return false, true, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return false, false, nil
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return false, true, fmt.Errorf("Get %q: unexpected status: %s", u.url, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return false, false, fmt.Errorf("Get %q: unexpected status: %s", u.url, resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, true, err
+	}
+
+	u.mux.Lock()
+	defer u.mux.Unlock()
+
+	changed = !bytes.Equal(u.content, content)
+	u.content = content
+	u.contentType = resp.Header.Get("Content-Type")
+	u.etag = resp.Header.Get("ETag")
+	u.lastModified = resp.Header.Get("Last-Modified")
+	return changed, false, nil
+}
+
+// format chooses the format of the content by the options, the Content-Type header,
+// the extension of the URL or by the content itself, in this order.
+func (u *urlSource) format(content []byte, contentType string) (*format, bool) {
+	if u.opts.ContentType != "" {
+		return formats.byName(u.opts.ContentType)
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		for _, name := range []string{"json", "yaml", "xml"} {
+			if strings.Contains(mediaType, name) {
+				return formats.byName(name)
+			}
+		}
+	}
+	if parsed, err := url.Parse(u.url); err == nil {
+		if f, ok := formats.byExtension(path.Ext(parsed.Path)); ok {
+			return f, true
+		}
+	}
+	return formats.byContent(content)
+}
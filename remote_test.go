@@ -0,0 +1,176 @@
+package settings
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitRemoteSuite struct {
+		suite.Suite
+	}
+
+	testHTTPServer struct {
+		mux         sync.Mutex
+		content     string
+		contentType string
+		etag        string
+		requests    int
+		notModified int
+		failures    int
+		header      http.Header
+	}
+)
+
+func (u unitRemoteSuite) TestNewFromURL() {
+	ts := &testHTTPServer{content: testJSONContentOther, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := NewFromURL(server.URL+"/settings", URLOptions{Header: http.Header{"Authorization": {"Bearer token"}}})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("other.content.string")
+	u.Equal(nil, err)
+	u.Equal("text", v)
+	u.Equal("Bearer token", ts.get().header.Get("Authorization"))
+
+	sm.Reload()
+	u.Equal(2, ts.get().requests)
+	u.Equal(1, ts.get().notModified)
+
+	v, err = sm.Get("other.content.string")
+	u.Equal(nil, err)
+	u.Equal("text", v)
+}
+
+func (u unitRemoteSuite) TestMergeURL() {
+	initTestOk()
+
+	ts := &testHTTPServer{content: "service:\n  name: Remote"}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := New(testYamlFilePAth).MergeURL(server.URL+"/settings.yaml", URLOptions{})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("Remote", v)
+
+	v, err = sm.Get("email.to")
+	u.Equal(nil, err)
+	u.Equal("user@gmail.com", v)
+
+	resetTest()
+}
+
+func (u unitRemoteSuite) TestURLErrors() {
+	ts := &testHTTPServer{content: testJSONContentOther, failures: 2}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := NewFromURL(server.URL, URLOptions{Retries: 2, Backoff: time.Millisecond})
+	u.Equal(nil, sm.Error)
+	u.Equal(3, ts.get().requests)
+
+	ts = &testHTTPServer{content: testJSONContentOther, failures: 2}
+	server2 := httptest.NewServer(ts)
+	defer server2.Close()
+
+	sm = NewFromURL(server2.URL, URLOptions{Retries: 1, Backoff: time.Millisecond})
+	u.Equal(fmt.Sprintf(`settings.NewFromURL :: Get %q: unexpected status: 503 Service Unavailable`, server2.URL), fmt.Sprint(sm.Error))
+
+	sm = NewFromURL(server2.URL+"/missing", URLOptions{})
+	u.Equal(fmt.Sprintf(`settings.NewFromURL :: Get "%s/missing": unexpected status: 404 Not Found`, server2.URL), fmt.Sprint(sm.Error))
+
+	sm = NewFromURL(server2.URL, URLOptions{ContentType: "ini"})
+	u.Equal(fmt.Sprintf(`settings.NewFromURL :: Get %q: unsupported content type`, server2.URL), fmt.Sprint(sm.Error))
+}
+
+func (u unitRemoteSuite) TestURLAutoReload() {
+	ts := &testHTTPServer{content: testJSONContentOther, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	reloaded := make(chan struct{}, 1)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := NewFromURL(server.URL, URLOptions{Interval: 5 * time.Millisecond})
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+
+	time.Sleep(20 * time.Millisecond)
+	u.Equal(0, len(reloaded))
+	u.NotEqual(0, ts.get().notModified)
+
+	ts.set(strings.ReplaceAll(testJSONContentOther, `"int": 1`, `"int": 1000`), `"v2"`)
+	<-reloaded
+	sm.StopAutoReload()
+
+	v, err := sm.Get("other.content.int")
+	u.Equal(nil, err)
+	u.Equal(float64(1000), v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+}
+
+func TestRemoteUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitRemoteSuite))
+}
+
+func (ts *testHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	ts.requests++
+	ts.header = r.Header
+
+	if strings.HasSuffix(r.URL.Path, "/missing") {
+		http.NotFound(w, r)
+		return
+	}
+	if ts.failures > 0 {
+		ts.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if ts.etag != "" && r.Header.Get("If-None-Match") == ts.etag {
+		ts.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if ts.contentType != "" {
+		w.Header().Set("Content-Type", ts.contentType)
+	}
+	if ts.etag != "" {
+		w.Header().Set("ETag", ts.etag)
+	}
+	_, _ = w.Write([]byte(ts.content))
+}
+
+func (ts *testHTTPServer) get() testHTTPServer {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	return testHTTPServer{requests: ts.requests, notModified: ts.notModified, header: ts.header}
+}
+
+func (ts *testHTTPServer) set(content, etag string) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	ts.content = content
+	ts.etag = etag
+}
@@ -1,12 +1,17 @@
 // This package was made, to easily get needed settings from a file.
-// Supported file types are: json and yaml.
+// Supported file types are: json, yaml and xml, further formats can be added by RegisterFormat.
 //
 // This package uses https://github.com/spf13/viper: Copyright © 2014 Steve Francia <spf@spf13.com>.
 package settings
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"strings"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
@@ -19,36 +24,95 @@ type fsNotify struct {
 }
 
 type Settings struct {
-	Data      *viper.Viper
-	Error     error
-	content   string
-	fileNames []string
-	mux       sync.Mutex
+	Data       *viper.Viper
+	Error      error
+	layers     []layer
+	fileNames  []string
+	profile    string
+	profiles   []string
+	subTree    string
+	overrides  []override
+	strategies []pathStrategy
+	origins    map[string][]Origin
+	merging    mergeContext
+	written    map[string][]byte
+	ctx        context.Context
+	cancel     context.CancelFunc
+	errs       chan error
+	changes    chan Change
+	mux        sync.Mutex
 }
 
 // New initializes settings from a file or from multiple files under given directory.
 func New(settingsFile string) *Settings {
 	s := &Settings{}
 	s.Data = viper.New()
-	return s.load(settingsFile)
+	return s.Merge(settingsFile)
 }
 
 // NewFromContent initializes settings from a given content.
+// The type of the content is guessed, see NewFromContentAs for an explicit one.
 func NewFromContent(content string) *Settings {
-	s := &Settings{content: content}
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeContent(content, "", "NewFromContent")
+}
+
+// NewFromContentAs initializes settings from a given content of the given type, e.g. "json" or "yaml".
+func NewFromContentAs(content, contentType string) *Settings {
+	s := &Settings{}
 	s.Data = viper.New()
-	ext := getExtensionByContent(content)
-	if ext == "unsupported" {
-		return &Settings{Error: fmt.Errorf("settings.NewFromContent :: unsupported content type")}
+	return s.mergeContent(content, contentType, "NewFromContentAs")
+}
+
+// NewFromReader initializes settings from a reader holding content of the given type.
+func NewFromReader(r io.Reader, contentType string) *Settings {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return &Settings{Error: fmt.Errorf("settings.NewFromReader :: %s", err)}
 	}
-	s.Data.SetConfigType(ext)
-	_ = s.Data.ReadConfig(bytes.NewBuffer([]byte(content)))
-	return s
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeContent(string(b), contentType, "NewFromReader")
 }
 
 // Merge merges initialized settings with a given file or directory.
 func (s *Settings) Merge(settingsFile string) *Settings {
-	return s.load(settingsFile)
+	if s.Error != nil {
+		return s
+	}
+	if err := s.addSource(&fileSource{file: settingsFile}, 0); err != nil {
+		return &Settings{Error: err}
+	}
+	return s
+}
+
+// NewFromFS initializes settings from a file or from multiple files under given directory of a file system,
+// e.g. an embed.FS. The files of a file system are not reported by GetSettingsFileNames and not watched by AutoReload.
+func NewFromFS(fsys fs.FS, settingsFile string) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.MergeFS(fsys, settingsFile)
+}
+
+// MergeFS merges initialized settings with a given file or directory of a file system.
+func (s *Settings) MergeFS(fsys fs.FS, settingsFile string) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	if err := s.addSource(&fsSource{fsys: fsys, file: settingsFile}, 0); err != nil {
+		return &Settings{Error: err}
+	}
+	return s
+}
+
+// MergeContent merges initialized settings with a given content of the given type.
+// An empty content type means that the type is guessed like by NewFromContent.
+func (s *Settings) MergeContent(content, contentType string) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeContent(content, contentType, "MergeContent")
 }
 
 // GetAllKeys returns all keys holding a value, regardless of where they are set.
@@ -72,41 +136,98 @@ func (s *Settings) GetAllSettings() (map[string]interface{}, error) {
 // SubTree is case-insensitive for a key.
 func (s *Settings) SubTree(prefix string) *Settings {
 	s.Data = s.Data.Sub(prefix)
+	s.origins = subOrigins(s.origins, strings.ToLower(prefix))
+	if s.subTree != "" {
+		prefix = s.subTree + "." + prefix
+	}
+	s.subTree = strings.ToLower(prefix)
 	return s
 }
 
 // GetSettingsFileNames returns the name of all settings files, whence settings manager was initialized.
+// Files of a git repository are reported with the commit they came from, e.g. "repo/config.yaml@<commit>".
 func (s *Settings) GetSettingsFileNames() ([]string, error) {
 	if s.Error != nil {
 		return nil, fmt.Errorf("settings.GetSettingsFileNames :: %s", s.Error)
 	}
-	return s.fileNames, nil
+
+	fileNames := s.fileNames[:len(s.fileNames):len(s.fileNames)]
+	for _, l := range s.layers {
+		if f, ok := l.source.(fileLister); ok {
+			fileNames = append(fileNames, f.fileNames()...)
+		}
+	}
+	return fileNames, nil
 }
 
 // Reload once it's called, will re-read the settings data.
+// Files and contents are merged again in the order they were added.
+// On failure the previous settings data is kept. The changed keys are reported by Changes.
 func (s *Settings) Reload() {
 	s.mux.Lock()
+	defer s.mux.Unlock()
 
-	content := s.content
-	s.Data = viper.New()
-
-	if content != "" {
-		s.Data = NewFromContent(content).Data
-	}
-
-	for _, fileName := range s.fileNames {
-		s.Data = s.load(fileName).Data
+	before := s.Data.AllSettings()
+	if err := s.rebuild(); err != nil {
+		log.Println("settings.Reload", err)
+		return
 	}
-
-	s.mux.Unlock()
+	s.reportChange(before, s.Data.AllSettings())
 }
 
 // AutoReload watching for settings file changes in the background
 // and reloads configuration if needed.
+// Remote and custom sources are watched in the background until StopAutoReload is called.
 func (s *Settings) AutoReload() {
 	for _, fileName := range s.fileNames {
 		s.Data.SetConfigFile(fileName)
 		s.Data.WatchConfig()
 		triggerReload(s)
 	}
+
+	s.mux.Lock()
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	if s.errs == nil {
+		s.errs = make(chan error, autoReloadErrorsSize)
+	}
+
+	var errs []error
+	for _, l := range s.layers {
+		events, err := l.source.Watch(s.ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", l.source.Name(), err))
+		} else if events != nil {
+			go s.watchSource(events)
+		}
+	}
+	s.mux.Unlock()
+
+	for _, err := range errs {
+		s.reportError(err)
+	}
+}
+
+// AutoReloadErrors returns a channel, which receives the errors of the background reload,
+// e.g. when a remote source cannot be reached. Errors are dropped while the channel is full.
+func (s *Settings) AutoReloadErrors() <-chan error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.errs == nil {
+		s.errs = make(chan error, autoReloadErrorsSize)
+	}
+	return s.errs
+}
+
+// StopAutoReload stops watching the remote and custom sources in the background.
+func (s *Settings) StopAutoReload() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+		s.ctx, s.cancel = nil, nil
+	}
 }
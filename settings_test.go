@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/suite"
@@ -34,6 +35,119 @@ func (u unitConfSuite) TestInitFromSource() {
 	resetTest()
 }
 
+func (u unitConfSuite) TestInitFromContentAs() {
+	sm := NewFromContentAs(testJSONContent, "json")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("email.to")
+	u.Equal(nil, err)
+	u.Equal("user@gmail.com", v)
+
+	sm = NewFromContentAs(`{"service": {"name": "ExampleService"}`, "json")
+	u.Equal("settings.NewFromContentAs :: While parsing config: unexpected end of JSON input", fmt.Sprint(sm.Error))
+
+	sm = NewFromContentAs(testYamlContent, "ini")
+	u.Equal("settings.NewFromContentAs :: unsupported content type: ini", fmt.Sprint(sm.Error))
+}
+
+func (u unitConfSuite) TestInitFromReader() {
+	sm := NewFromReader(strings.NewReader(testYamlContent), "yaml")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	sm = NewFromReader(strings.NewReader(testBadYamlContent), "yaml")
+	u.Equal("settings.NewFromReader :: While parsing config: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `/* BAD ...` into map[string]interface {}", fmt.Sprint(sm.Error))
+}
+
+func (u unitConfSuite) TestMergeContent() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testJsonFileOtherPAth, []byte(testJSONContentOther), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testYamlFilePAth).
+		MergeContent(`{"other": {"content": {"string": "from content"}}}`, "json").
+		Merge(testJsonFileOtherPAth).
+		MergeContent("service:\n  name: FromContent", "yaml")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("other.content.string")
+	u.Equal(nil, err)
+	u.Equal("text", v)
+
+	v, err = sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("FromContent", v)
+
+	saveFile(u, testJsonFileOtherPAth, `{"other": {"content": {"string": "changed"}}}`)
+	sm.Reload()
+
+	v, err = sm.Get("other.content.string")
+	u.Equal(nil, err)
+	u.Equal("changed", v)
+
+	v, err = sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("FromContent", v)
+
+	sm = New(testYamlFilePAth).MergeContent(`{"broken": `, "json")
+	u.Equal("settings.MergeContent :: While parsing config: unexpected end of JSON input", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitConfSuite) TestInitFromFS() {
+	initTestOk()
+
+	fsys := fstest.MapFS{
+		"defaults/app.yaml":        {Data: []byte(testYamlContent)},
+		"defaults/nested/app.json": {Data: []byte(testJSONContentOther)},
+		"defaults/README.md":       {Data: []byte("# not a settings file")},
+	}
+
+	sm := NewFromFS(fsys, "defaults/app.yaml")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	sm = NewFromFS(fsys, "defaults")
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.Get("other.content.string")
+	u.Equal(nil, err)
+	u.Equal("text", v)
+
+	fileNames, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Empty(fileNames)
+
+	err = ioutil.WriteFile(testYamlFileOtherPAth, []byte("service:\n  name: Override"), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm = NewFromFS(fsys, "defaults").Merge(testYamlFileOtherPAth)
+	u.Equal(nil, sm.Error)
+
+	sm.Reload()
+
+	v, err = sm.Get("service.name")
+	u.Equal(nil, err)
+	u.Equal("Override", v)
+
+	v, err = sm.Get("email.to")
+	u.Equal(nil, err)
+	u.Equal("user@gmail.com", v)
+
+	sm = NewFromFS(fsys, "defaults/missing.yaml")
+	u.Equal("open defaults/missing.yaml: file does not exist", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
 func (u unitConfSuite) TestInit() {
 	initTestOk()
 
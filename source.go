@@ -0,0 +1,246 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/viper"
+)
+
+// Source is a provider of settings, which can be added to the settings by AddSource.
+// It is merged, reloaded and watched in the same way as the built-in files and remote sources.
+type Source interface {
+	// Name identifies the source, e.g. a file name or an address.
+	Name() string
+
+	// Load reads the settings of the source.
+	Load(ctx context.Context) (map[string]interface{}, error)
+
+	// Watch reports the changes of the source until the context is done, then the channel is closed.
+	// A source, which cannot be watched, returns a nil channel.
+	Watch(ctx context.Context) (<-chan Event, error)
+}
+
+// Event is sent by a watched source, when it has changed or when it cannot be watched.
+type Event struct {
+	Source string
+	Err    error
+}
+
+// layer is a single source of settings, which is replayed by Reload in the order of priority.
+type layer struct {
+	source   Source
+	priority int
+}
+
+// documentSource is implemented by the sources of settings files, which merge their documents
+// into the settings themselves, so that the profiles of multi-document streams are applied.
+type documentSource interface {
+	mergeInto(s *Settings) error
+}
+
+// fileLister is implemented by the sources, which report their files by GetSettingsFileNames.
+type fileLister interface {
+	fileNames() []string
+}
+
+// AddSource merges initialized settings with a custom source.
+//
+// Sources of a higher priority take precedence over the ones of a lower priority,
+// sources of the same priority are merged in the order they were added.
+// All built-in sources, e.g. the files added by New and Merge, have priority 0.
+func (s *Settings) AddSource(src Source, priority int) *Settings {
+	if s.Error != nil {
+		return s
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.addSource(src, priority); err != nil {
+		return &Settings{Error: fmt.Errorf("settings.AddSource :: %s :: %s", src.Name(), err)}
+	}
+	return s
+}
+
+// addSource merges a source on top of the settings, if it has the highest priority.
+// Otherwise it is inserted among the layers, and the settings are rebuilt.
+func (s *Settings) addSource(src Source, priority int) error {
+	l := layer{source: src, priority: priority}
+
+	i := len(s.layers)
+	for i > 0 && s.layers[i-1].priority > priority {
+		i--
+	}
+	if i == len(s.layers) {
+		if err := s.apply(src, i); err != nil {
+			return err
+		}
+		s.layers = append(s.layers, l)
+		return nil
+	}
+
+	layers := s.layers
+	s.layers = make([]layer, 0, len(layers)+1)
+	s.layers = append(s.layers, layers[:i]...)
+	s.layers = append(s.layers, l)
+	s.layers = append(s.layers, layers[i:]...)
+
+	if err := s.rebuild(); err != nil {
+		s.layers = layers
+		return err
+	}
+	return nil
+}
+
+// apply merges a source being the given layer of the settings.
+func (s *Settings) apply(src Source, layer int) error {
+	s.merging = mergeContext{origin: Origin{Source: src.Name(), Layer: layer}}
+	defer func() {
+		s.merging = mergeContext{}
+	}()
+
+	if d, ok := src.(documentSource); ok {
+		return d.mergeInto(s)
+	}
+
+	m, err := src.Load(context.Background())
+	if err != nil {
+		return err
+	}
+	return s.mergeConfigMap(m)
+}
+
+// loadDocuments loads a source of settings files on its own, without selecting a profile.
+func loadDocuments(src documentSource) (map[string]interface{}, error) {
+	s := &Settings{Data: viper.New()}
+	if err := src.mergeInto(s); err != nil {
+		return nil, err
+	}
+	return s.Data.AllSettings(), nil
+}
+
+// fileSource is a settings file or a directory of settings files.
+// The files are watched by AutoReload through their names, see GetSettingsFileNames.
+type fileSource struct {
+	file string
+	opts DirectoryOptions
+}
+
+func (f *fileSource) Name() string {
+	return f.file
+}
+
+func (f *fileSource) Load(ctx context.Context) (map[string]interface{}, error) {
+	return loadDocuments(f)
+}
+
+func (f *fileSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return nil, nil
+}
+
+func (f *fileSource) mergeInto(s *Settings) error {
+	return s.loadDirectory(f.file, f.opts).Error
+}
+
+// fsSource is a settings file or a directory of settings files of a file system.
+type fsSource struct {
+	fsys fs.FS
+	file string
+}
+
+func (f *fsSource) Name() string {
+	return f.file
+}
+
+func (f *fsSource) Load(ctx context.Context) (map[string]interface{}, error) {
+	return loadDocuments(f)
+}
+
+func (f *fsSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return nil, nil
+}
+
+func (f *fsSource) mergeInto(s *Settings) error {
+	return s.loadFS(f.fsys, f.file).Error
+}
+
+// contentSource is a content of a known type.
+type contentSource struct {
+	content string
+	format  *format
+}
+
+func (c *contentSource) Name() string {
+	return c.format.name
+}
+
+func (c *contentSource) Load(ctx context.Context) (map[string]interface{}, error) {
+	return loadDocuments(c)
+}
+
+func (c *contentSource) Watch(ctx context.Context) (<-chan Event, error) {
+	return nil, nil
+}
+
+func (c *contentSource) mergeInto(s *Settings) error {
+	docs, err := c.format.decodeDocuments([]byte(c.content))
+	if err != nil {
+		return err
+	}
+	if c.format.positions != nil {
+		if positions := c.format.positions([]byte(c.content)); len(positions) == len(docs) {
+			s.merging.positions = positions
+		}
+	}
+	s.merging.include = &includeContext{}
+	return s.mergeDocuments(docs)
+}
+
+// remoteAdapter turns a built-in remote source into a Source.
+type remoteAdapter struct {
+	remote remoteSource
+}
+
+func (r remoteAdapter) Name() string {
+	return r.remote.name()
+}
+
+func (r remoteAdapter) Load(ctx context.Context) (map[string]interface{}, error) {
+	return r.remote.load()
+}
+
+func (r remoteAdapter) Watch(ctx context.Context) (<-chan Event, error) {
+	events := make(chan Event)
+	send := func(e Event) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(events)
+		r.remote.watch(ctx.Done(), func() {
+			send(Event{Source: r.Name()})
+		}, func(err error) {
+			send(Event{Source: r.Name(), Err: err})
+		})
+	}()
+	return events, nil
+}
+
+func (r remoteAdapter) fileNames() []string {
+	if f, ok := r.remote.(fileLister); ok {
+		return f.fileNames()
+	}
+	return nil
+}
+
+func (r remoteAdapter) offline() (OfflineSource, bool) {
+	if o, ok := r.remote.(offlineReporter); ok {
+		return o.offline()
+	}
+	return OfflineSource{}, false
+}
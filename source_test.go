@@ -0,0 +1,168 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitSourceSuite struct {
+		suite.Suite
+	}
+
+	// testSource is a custom source serving its settings from memory.
+	testSource struct {
+		name string
+
+		mux      sync.Mutex
+		settings map[string]interface{}
+		err      error
+		events   chan Event
+	}
+)
+
+func (u unitSourceSuite) TestAddSource() {
+	initTestOk()
+
+	high := newTestSource("high", map[string]interface{}{"service": map[string]interface{}{"name": "High"}})
+	low := newTestSource("low", map[string]interface{}{
+		"service": map[string]interface{}{"name": "Low", "owner": "team"},
+		"server":  map[string]interface{}{"timeout": map[string]interface{}{"read": 1}},
+	})
+
+	sm := New(testYamlFilePAth).AddSource(high, 10).AddSource(low, -1)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("High", v)
+
+	v, err = sm.GetString("service.owner")
+	u.Equal(nil, err)
+	u.Equal("team", v)
+
+	i, err := sm.GetInt("server.timeout.read")
+	u.Equal(nil, err)
+	u.Equal(5, i)
+
+	sm = sm.MergeContent(`{"service": {"name": "Content"}}`, "json")
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("High", v)
+
+	high.set(map[string]interface{}{"service": map[string]interface{}{"name": "Reloaded"}}, nil)
+	sm.Reload()
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Reloaded", v)
+
+	fileNames, err := sm.GetSettingsFileNames()
+	u.Equal(nil, err)
+	u.Equal([]string{"settings/test.yaml"}, fileNames)
+
+	failing := newTestSource("failing", nil)
+	failing.set(nil, errors.New("unavailable"))
+
+	sm = New(testYamlFilePAth).AddSource(failing, 0)
+	u.Equal("settings.AddSource :: failing :: unavailable", fmt.Sprint(sm.Error))
+
+	sm = New(testYamlFilePAth).AddSource(high, 1).AddSource(failing, 0)
+	u.Equal("settings.AddSource :: failing :: unavailable", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitSourceSuite) TestSourceAutoReload() {
+	initTestOk()
+
+	reloaded := make(chan struct{}, 10)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	src := newTestSource("custom", map[string]interface{}{"service": map[string]interface{}{"name": "Custom"}})
+	sm := NewFromContent(testYamlContent).AddSource(src, 0)
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+
+	src.set(map[string]interface{}{"service": map[string]interface{}{"name": "Changed"}}, nil)
+	src.events <- Event{Source: src.Name()}
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Changed", v)
+
+	src.events <- Event{Source: src.Name(), Err: errors.New("lost connection")}
+
+	select {
+	case err := <-sm.AutoReloadErrors():
+		u.Equal("lost connection", fmt.Sprint(err))
+	case <-time.After(5 * time.Second):
+		u.Fail("error was not reported")
+	}
+	sm.StopAutoReload()
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+	resetTest()
+}
+
+func TestSourceUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitSourceSuite))
+}
+
+func newTestSource(name string, settings map[string]interface{}) *testSource {
+	return &testSource{name: name, settings: settings, events: make(chan Event)}
+}
+
+func (t *testSource) set(settings map[string]interface{}, err error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.settings, t.err = settings, err
+}
+
+func (t *testSource) Name() string {
+	return t.name
+}
+
+func (t *testSource) Load(ctx context.Context) (map[string]interface{}, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return copyMap(t.settings), t.err
+}
+
+func (t *testSource) Watch(ctx context.Context) (<-chan Event, error) {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-t.events:
+				events <- e
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,202 @@
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultSQLTable    = "settings"
+	defaultSQLInterval = 30 * time.Second
+)
+
+// SQLOptions configures a database/sql settings source.
+//
+// The settings are stored in a table of (key, value, type, updated_at) columns.
+// Keys are dotted paths, e.g. "db.host". The type of a value is one of
+// string, int, float, bool, json or yaml, an empty type means string.
+//
+// The default queries qualify the columns by the table, because "key" is a reserved word
+// of MySQL and MariaDB. Databases rejecting the default queries, or tables with other columns,
+// need a Query, e.g. "SELECT [key], [value], [type] FROM settings" on SQL Server.
+type SQLOptions struct {
+	// Table holding the settings, defaults to "settings".
+	Table string
+
+	// Query selects the key, value and type columns in this order, their names do not matter.
+	// It defaults to "SELECT <Table>.key, <Table>.value, <Table>.type FROM <Table>".
+	Query string
+
+	// VersionQuery selects a single value, which changes whenever the settings change,
+	// defaults to "SELECT max(updated_at) FROM <Table>".
+	VersionQuery string
+
+	// Interval of polling the VersionQuery by AutoReload, defaults to 30 seconds.
+	Interval time.Duration
+
+	// Cache stores the last successful payload, which is loaded when the source cannot be reached.
+	Cache CacheOptions
+}
+
+type sqlSource struct {
+	db   *sql.DB
+	opts SQLOptions
+
+	mux     sync.Mutex
+	version string
+}
+
+// NewFromSQL initializes settings from a table of a database.
+func NewFromSQL(db *sql.DB, opts SQLOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeRemote(withCache(newSQLSource(db, opts), opts.Cache), "NewFromSQL")
+}
+
+// MergeSQL merges initialized settings with a table of a database.
+// AutoReload polls the version of the table, e.g. max(updated_at), and reloads the settings when it changes.
+func (s *Settings) MergeSQL(db *sql.DB, opts SQLOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeRemote(withCache(newSQLSource(db, opts), opts.Cache), "MergeSQL")
+}
+
+func newSQLSource(db *sql.DB, opts SQLOptions) *sqlSource {
+	if opts.Table == "" {
+		opts.Table = defaultSQLTable
+	}
+	if opts.Query == "" {
+		opts.Query = fmt.Sprintf("SELECT %[1]s.key, %[1]s.value, %[1]s.type FROM %[1]s", opts.Table)
+	}
+	if opts.VersionQuery == "" {
+		opts.VersionQuery = fmt.Sprintf("SELECT max(updated_at) FROM %s", opts.Table)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultSQLInterval
+	}
+	return &sqlSource{db: db, opts: opts}
+}
+
+func (q *sqlSource) name() string {
+	return "sql:" + q.opts.Table
+}
+
+func (q *sqlSource) load() (map[string]interface{}, error) {
+	ctx := context.Background()
+
+	version, err := q.queryVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, q.opts.Query)
+	if err != nil {
+		return nil, fmt.Errorf("sql: %s :: %s", q.opts.Table, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	m := map[string]interface{}{}
+	for rows.Next() {
+		var (
+			key, value string
+			valueType  sql.NullString
+		)
+		if err := rows.Scan(&key, &value, &valueType); err != nil {
+			return nil, fmt.Errorf("sql: %s :: %s", q.opts.Table, err)
+		}
+		v, err := decodeSQLValue(value, valueType.String)
+		if err != nil {
+			return nil, fmt.Errorf("sql: %s :: key: %s :: %s", q.opts.Table, key, err)
+		}
+		setNested(m, strings.Split(key, "."), v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sql: %s :: %s", q.opts.Table, err)
+	}
+
+	q.mux.Lock()
+	q.version = version
+	q.mux.Unlock()
+
+	return m, nil
+}
+
+func (q *sqlSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(q.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			version, err := q.queryVersion(ctx)
+			if ctx.Err() != nil {
+				return
+			} else if err != nil {
+				report(err)
+				continue
+			}
+
+			q.mux.Lock()
+			changed := version != q.version
+			q.mux.Unlock()
+
+			if changed {
+				reload()
+			}
+		}
+	}
+}
+
+func (q *sqlSource) queryVersion(ctx context.Context) (string, error) {
+	var version interface{}
+	if err := q.db.QueryRowContext(ctx, q.opts.VersionQuery).Scan(&version); err != nil {
+		return "", fmt.Errorf("sql: %s :: %s", q.opts.Table, err)
+	}
+	if b, ok := version.([]byte); ok {
+		return string(b), nil
+	}
+	return fmt.Sprint(version), nil
+}
+
+func decodeSQLValue(value, valueType string) (interface{}, error) {
+	switch strings.ToLower(valueType) {
+	case "", "string", "text":
+		return value, nil
+	case "int", "integer":
+		return strconv.Atoi(value)
+	case "float", "float64", "number":
+		return strconv.ParseFloat(value, 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(value)
+	case "json":
+		var v interface{}
+		err := json.Unmarshal([]byte(value), &v)
+		return v, err
+	case "yaml":
+		var v interface{}
+		err := yaml.Unmarshal([]byte(value), &v)
+		return v, err
+	}
+	return nil, fmt.Errorf("unsupported value type: %s", valueType)
+}
@@ -0,0 +1,262 @@
+package settings
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitSQLSuite struct {
+		suite.Suite
+	}
+
+	// testSQLDriver is a fake driver.Driver serving a single settings table from memory.
+	testSQLDriver struct {
+		mux       sync.Mutex
+		rows      [][]driver.Value
+		updatedAt time.Time
+	}
+
+	testSQLConn struct {
+		driver *testSQLDriver
+	}
+
+	testSQLStmt struct {
+		driver *testSQLDriver
+		query  string
+	}
+
+	testSQLRows struct {
+		columns []string
+		rows    [][]driver.Value
+	}
+)
+
+var testSQL = &testSQLDriver{}
+
+func init() {
+	sql.Register("settings-test", testSQL)
+}
+
+func (u unitSQLSuite) TestNewFromSQL() {
+	testSQL.set([][]driver.Value{
+		{"db.host", "db.example.com", "string"},
+		{"db.port", "5432", "int"},
+		{"db.ratio", "0.5", "float"},
+		{"feature.enabled", "true", "bool"},
+		{"feature.list", `["a", "b"]`, "json"},
+		{"feature.map", "key: value", "yaml"},
+		{"untyped", "text", nil},
+	})
+
+	db, err := sql.Open("settings-test", "")
+	u.Equal(nil, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	sm := NewFromSQL(db, SQLOptions{})
+	u.Equal(nil, sm.Error)
+
+	s, err := sm.GetString("db.host")
+	u.Equal(nil, err)
+	u.Equal("db.example.com", s)
+
+	i, err := sm.GetInt("db.port")
+	u.Equal(nil, err)
+	u.Equal(5432, i)
+
+	f, err := sm.GetFloat64("db.ratio")
+	u.Equal(nil, err)
+	u.Equal(0.5, f)
+
+	b, err := sm.GetBool("feature.enabled")
+	u.Equal(nil, err)
+	u.Equal(true, b)
+
+	sl, err := sm.GetStringSlice("feature.list")
+	u.Equal(nil, err)
+	u.Equal([]string{"a", "b"}, sl)
+
+	s, err = sm.GetString("feature.map.key")
+	u.Equal(nil, err)
+	u.Equal("value", s)
+
+	s, err = sm.GetString("untyped")
+	u.Equal(nil, err)
+	u.Equal("text", s)
+
+	testSQL.set([][]driver.Value{{"db.port", "not a number", "int"}})
+
+	sm = NewFromSQL(db, SQLOptions{})
+	u.Equal(`settings.NewFromSQL :: sql: settings :: key: db.port :: strconv.Atoi: parsing "not a number": invalid syntax`, fmt.Sprint(sm.Error))
+
+	testSQL.set([][]driver.Value{{"db.port", "5432", "duration"}})
+
+	sm = NewFromSQL(db, SQLOptions{})
+	u.Equal(`settings.NewFromSQL :: sql: settings :: key: db.port :: unsupported value type: duration`, fmt.Sprint(sm.Error))
+
+	sm = NewFromSQL(db, SQLOptions{Table: "missing"})
+	u.Equal(`settings.NewFromSQL :: sql: missing :: unknown query: SELECT max(updated_at) FROM missing`, fmt.Sprint(sm.Error))
+}
+
+func (u unitSQLSuite) TestNewFromSQLQuery() {
+	testSQL.set([][]driver.Value{
+		{"db.host", "db.example.com", "string"},
+		{"db.port", "3306", "int"},
+	})
+
+	db, err := sql.Open("settings-test", "")
+	u.Equal(nil, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	sm := NewFromSQL(db, SQLOptions{
+		Table:        "tunables",
+		Query:        "SELECT name AS k, val AS v, kind AS t FROM tunables",
+		VersionQuery: "SELECT max(changed) FROM tunables",
+	})
+	u.Equal(nil, sm.Error)
+
+	s, err := sm.GetString("db.host")
+	u.Equal(nil, err)
+	u.Equal("db.example.com", s)
+
+	i, err := sm.GetInt("db.port")
+	u.Equal(nil, err)
+	u.Equal(3306, i)
+}
+
+func (u unitSQLSuite) TestMergeSQLAutoReload() {
+	initTestOk()
+
+	testSQL.set([][]driver.Value{{"service.name", "FromSQL", "string"}})
+
+	db, err := sql.Open("settings-test", "")
+	u.Equal(nil, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	reloaded := make(chan struct{}, 1)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := New(testYamlFilePAth).MergeSQL(db, SQLOptions{Interval: 5 * time.Millisecond})
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("FromSQL", v)
+
+	time.Sleep(20 * time.Millisecond)
+	u.Equal(0, len(reloaded))
+
+	testSQL.set([][]driver.Value{{"service.name", "Changed", "string"}})
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+	sm.StopAutoReload()
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Changed", v)
+
+	v, err = sm.GetString("email.to")
+	u.Equal(nil, err)
+	u.Equal("user@gmail.com", v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+	resetTest()
+}
+
+func TestSQLUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitSQLSuite))
+}
+
+func (d *testSQLDriver) set(rows [][]driver.Value) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.rows = rows
+	d.updatedAt = d.updatedAt.Add(time.Second)
+}
+
+func (d *testSQLDriver) Open(name string) (driver.Conn, error) {
+	return &testSQLConn{driver: d}, nil
+}
+
+func (c *testSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &testSQLStmt{driver: c.driver, query: query}, nil
+}
+
+func (c *testSQLConn) Close() error {
+	return nil
+}
+
+func (c *testSQLConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+func (s *testSQLStmt) Close() error {
+	return nil
+}
+
+func (s *testSQLStmt) NumInput() int {
+	return 0
+}
+
+func (s *testSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("exec is not supported")
+}
+
+func (s *testSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.mux.Lock()
+	defer s.driver.mux.Unlock()
+
+	switch s.query {
+	case "SELECT settings.key, settings.value, settings.type FROM settings":
+		return &testSQLRows{columns: []string{"key", "value", "type"}, rows: s.driver.rows}, nil
+	case "SELECT name AS k, val AS v, kind AS t FROM tunables":
+		return &testSQLRows{columns: []string{"k", "v", "t"}, rows: s.driver.rows}, nil
+	case "SELECT max(changed) FROM tunables":
+		return &testSQLRows{columns: []string{"max"}, rows: [][]driver.Value{{s.driver.updatedAt}}}, nil
+	case "SELECT max(updated_at) FROM settings":
+		return &testSQLRows{columns: []string{"max"}, rows: [][]driver.Value{{s.driver.updatedAt}}}, nil
+	}
+	return nil, fmt.Errorf("unknown query: %s", s.query)
+}
+
+func (r *testSQLRows) Columns() []string {
+	return r.columns
+}
+
+func (r *testSQLRows) Close() error {
+	return nil
+}
+
+func (r *testSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,302 @@
+package settings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultVaultAddress = "http://127.0.0.1:8200"
+	defaultVaultMount   = "secret"
+	defaultVaultAppRole = "approle"
+	defaultVaultTTL     = 5 * time.Minute
+)
+
+// VaultOptions configures a HashiCorp Vault KV v2 secrets source.
+// Secrets are kept in memory only, they are never written to the disk in plain text:
+// an offline cache of the secrets has to be encrypted by a Cache.Key.
+type VaultOptions struct {
+	// Address of the Vault HTTP API, defaults to $VAULT_ADDR or http://127.0.0.1:8200.
+	Address string
+
+	// Token is used for token authentication, defaults to $VAULT_TOKEN.
+	Token string
+
+	// RoleID and SecretID are used for AppRole authentication, when Token is empty.
+	// AppRoleMount is the mount path of the AppRole auth method, defaults to "approle".
+	RoleID       string
+	SecretID     string
+	AppRoleMount string
+
+	// Mount is the mount path of the KV v2 secrets engine, defaults to "secret".
+	Mount string
+
+	// Subtree is the key, under which the secrets are merged, e.g. "secrets.db".
+	// If empty, the secrets are merged to the root.
+	Subtree string
+
+	// TTL is the interval of re-reading the secrets by AutoReload, when they have no lease.
+	// It defaults to 5 minutes.
+	TTL time.Duration
+
+	// Client is used to send the requests, defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Cache stores the last successful payload, which is loaded when the source cannot be reached.
+	// The Key of the cache is required, the secrets are not cached unencrypted.
+	Cache CacheOptions
+}
+
+type vaultSource struct {
+	path string
+	opts VaultOptions
+
+	mux         sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	lease       time.Duration
+	data        map[string]interface{}
+}
+
+// NewFromVault initializes settings from a secret of a Vault KV v2 secrets engine.
+func NewFromVault(secretPath string, opts VaultOptions) *Settings {
+	s := &Settings{}
+	s.Data = viper.New()
+	return s.mergeVault(secretPath, opts, "NewFromVault")
+}
+
+// MergeVault merges initialized settings with a secret of a Vault KV v2 secrets engine.
+// AutoReload re-reads the secret, when its lease or the configured TTL expires.
+func (s *Settings) MergeVault(secretPath string, opts VaultOptions) *Settings {
+	if s.Error != nil {
+		return s
+	}
+	return s.mergeVault(secretPath, opts, "MergeVault")
+}
+
+func (s *Settings) mergeVault(secretPath string, opts VaultOptions, funcName string) *Settings {
+	if opts.Cache.File != "" && len(opts.Cache.Key) == 0 {
+		return &Settings{Error: fmt.Errorf("settings.%s :: %s :: the cache of secrets requires a key", funcName, secretPath)}
+	}
+	return s.mergeRemote(withCache(newVaultSource(secretPath, opts), opts.Cache), funcName)
+}
+
+func newVaultSource(secretPath string, opts VaultOptions) *vaultSource {
+	if opts.Address == "" {
+		opts.Address = os.Getenv("VAULT_ADDR")
+	}
+	if opts.Address == "" {
+		opts.Address = defaultVaultAddress
+	}
+	if opts.Token == "" && opts.RoleID == "" {
+		opts.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if opts.AppRoleMount == "" {
+		opts.AppRoleMount = defaultVaultAppRole
+	}
+	if opts.Mount == "" {
+		opts.Mount = defaultVaultMount
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultVaultTTL
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &vaultSource{path: strings.Trim(secretPath, "/"), opts: opts, token: opts.Token}
+}
+
+func (v *vaultSource) name() string {
+	return "vault:" + v.path
+}
+
+func (v *vaultSource) load() (map[string]interface{}, error) {
+	if _, err := v.read(context.Background()); err != nil {
+		return nil, err
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	m := map[string]interface{}{}
+	data := copyMap(v.data)
+	if v.opts.Subtree == "" {
+		return data, nil
+	}
+	setNested(m, strings.Split(v.opts.Subtree, "."), data)
+	return m, nil
+}
+
+func (v *vaultSource) watch(stop <-chan struct{}, reload func(), report func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(v.refreshInterval()):
+		}
+
+		changed, err := v.read(ctx)
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			report(err)
+		} else if changed {
+			reload()
+		}
+	}
+}
+
+// refreshInterval returns the time until the lease of the secret or the TTL expires.
+func (v *vaultSource) refreshInterval() time.Duration {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if v.lease > 0 {
+		return v.lease
+	}
+	return v.opts.TTL
+}
+
+// read reads the secret, logging in by AppRole first if needed, and reports whether it has changed.
+func (v *vaultSource) read(ctx context.Context) (bool, error) {
+	if err := v.login(ctx); err != nil {
+		return false, err
+	}
+
+	var resp struct {
+		LeaseDuration int `json:"lease_duration"`
+		Data          struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("/v1/%s/data/%s", strings.Trim(v.opts.Mount, "/"), v.path)
+	status, err := v.request(ctx, http.MethodGet, path, nil, &resp)
+	if status == http.StatusForbidden && v.opts.RoleID != "" {
+		// The token might have been revoked: log in again once.
+		v.mux.Lock()
+		v.tokenExpiry = time.Time{}
+		v.token = ""
+		v.mux.Unlock()
+		if err := v.login(ctx); err != nil {
+			return false, err
+		}
+		_, err = v.request(ctx, http.MethodGet, path, nil, &resp)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	changed := !reflect.DeepEqual(v.data, resp.Data.Data)
+	v.data = resp.Data.Data
+	v.lease = time.Duration(resp.LeaseDuration) * time.Second
+	return changed, nil
+}
+
+func (v *vaultSource) login(ctx context.Context) error {
+	v.mux.Lock()
+	valid := v.token != "" && (v.tokenExpiry.IsZero() || time.Now().Before(v.tokenExpiry))
+	v.mux.Unlock()
+
+	if valid || v.opts.RoleID == "" {
+		return nil
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": v.opts.RoleID, "secret_id": v.opts.SecretID}
+	path := fmt.Sprintf("/v1/auth/%s/login", strings.Trim(v.opts.AppRoleMount, "/"))
+	if _, err := v.request(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return err
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	v.token = resp.Auth.ClientToken
+	v.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		v.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	}
+	return nil
+}
+
+func (v *vaultSource) request(ctx context.Context, method, path string, body, result interface{}) (int, error) {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(v.opts.Address, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+
+	v.mux.Lock()
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+	v.mux.Unlock()
+
+	resp, err := v.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&vaultErr)
+		if len(vaultErr.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("vault: %s :: %s: %s", path, resp.Status, strings.Join(vaultErr.Errors, ", "))
+		}
+		return resp.StatusCode, fmt.Errorf("vault: %s :: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return resp.StatusCode, fmt.Errorf("vault: %s :: %s", path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// copyMap returns a deep copy of the nested maps, so merging cannot alter the original.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = copyMap(nested)
+		}
+		c[key] = value
+	}
+	return c
+}
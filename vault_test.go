@@ -0,0 +1,202 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitVaultSuite struct {
+		suite.Suite
+	}
+
+	testVaultServer struct {
+		mux     sync.Mutex
+		secrets map[string]map[string]interface{}
+		tokens  map[string]bool
+		logins  int
+		reads   int
+	}
+)
+
+func (u unitVaultSuite) TestNewFromVaultToken() {
+	ts := newTestVaultServer()
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := NewFromVault("app/db", VaultOptions{Address: server.URL, Token: "root"})
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("password")
+	u.Equal(nil, err)
+	u.Equal("s3cr3t", v)
+
+	sm = NewFromVault("app/db", VaultOptions{Address: server.URL, Token: "invalid"})
+	u.Equal("settings.NewFromVault :: vault: /v1/secret/data/app/db :: 403 Forbidden: permission denied", fmt.Sprint(sm.Error))
+
+	sm = NewFromVault("app/missing", VaultOptions{Address: server.URL, Token: "root"})
+	u.Equal("settings.NewFromVault :: vault: /v1/secret/data/app/missing :: 404 Not Found", fmt.Sprint(sm.Error))
+
+	sm = NewFromVault("app/db", VaultOptions{Address: server.URL, Token: "root", Cache: CacheOptions{File: "vault.cache"}})
+	u.Equal("settings.NewFromVault :: app/db :: the cache of secrets requires a key", fmt.Sprint(sm.Error))
+
+	sm = NewFromVault("app/db", VaultOptions{Address: server.URL, Token: "root"}).
+		MergeVault("app/db", VaultOptions{Address: server.URL, Token: "root", Cache: CacheOptions{File: "vault.cache"}})
+	u.Equal("settings.MergeVault :: app/db :: the cache of secrets requires a key", fmt.Sprint(sm.Error))
+}
+
+func (u unitVaultSuite) TestMergeVaultAppRole() {
+	initTestOk()
+
+	ts := newTestVaultServer()
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	sm := New(testYamlFilePAth).MergeVault("app/db", VaultOptions{
+		Address:  server.URL,
+		RoleID:   "role",
+		SecretID: "secret",
+		Subtree:  "secrets.db",
+	})
+	u.Equal(nil, sm.Error)
+	u.Equal(1, ts.count().logins)
+
+	v, err := sm.GetString("secrets.db.password")
+	u.Equal(nil, err)
+	u.Equal("s3cr3t", v)
+
+	v, err = sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	ts.revokeTokens()
+	sm.Reload()
+	u.Equal(2, ts.count().logins)
+
+	v, err = sm.GetString("secrets.db.password")
+	u.Equal(nil, err)
+	u.Equal("s3cr3t", v)
+
+	sm = NewFromVault("app/db", VaultOptions{Address: server.URL, RoleID: "unknown"})
+	u.Equal("settings.NewFromVault :: vault: /v1/auth/approle/login :: 400 Bad Request: invalid role or secret ID", fmt.Sprint(sm.Error))
+
+	resetTest()
+}
+
+func (u unitVaultSuite) TestVaultAutoReload() {
+	ts := newTestVaultServer()
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	reloaded := make(chan struct{}, 1)
+	tmpTriggerRemoteReload := triggerRemoteReload
+	triggerRemoteReload = func(s *Settings) func() {
+		return func() {
+			s.Reload()
+			reloaded <- struct{}{}
+		}
+	}
+
+	sm := NewFromVault("app/db", VaultOptions{Address: server.URL, Token: "root", TTL: 5 * time.Millisecond})
+	u.Equal(nil, sm.Error)
+	sm.AutoReload()
+
+	time.Sleep(20 * time.Millisecond)
+	u.Equal(0, len(reloaded))
+	u.NotEqual(1, ts.count().reads)
+
+	ts.put("app/db", map[string]interface{}{"password": "rotated"})
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		u.Fail("settings were not reloaded")
+	}
+	sm.StopAutoReload()
+
+	v, err := sm.GetString("password")
+	u.Equal(nil, err)
+	u.Equal("rotated", v)
+
+	triggerRemoteReload = tmpTriggerRemoteReload
+}
+
+func TestVaultUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitVaultSuite))
+}
+
+func newTestVaultServer() *testVaultServer {
+	return &testVaultServer{
+		secrets: map[string]map[string]interface{}{
+			"app/db": {"password": "s3cr3t", "user": "app"},
+		},
+		tokens: map[string]bool{"root": true},
+	}
+}
+
+func (ts *testVaultServer) put(path string, data map[string]interface{}) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	ts.secrets[path] = data
+}
+
+func (ts *testVaultServer) revokeTokens() {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	ts.tokens = map[string]bool{"root": true}
+}
+
+func (ts *testVaultServer) count() testVaultServer {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	return testVaultServer{logins: ts.logins, reads: ts.reads}
+}
+
+// ServeHTTP is a minimal stand-in of the AppRole login and the KV v2 read endpoints of Vault.
+func (ts *testVaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["role_id"] != "role" || req["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		ts.logins++
+		token := fmt.Sprintf("token-%d", ts.logins)
+		ts.tokens[token] = true
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600},
+		})
+		return
+	}
+
+	if !ts.tokens[r.Header.Get("X-Vault-Token")] {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	secret, ok := ts.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+		return
+	}
+	ts.reads++
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"lease_duration": 0,
+		"data":           map[string]interface{}{"data": secret, "metadata": map[string]interface{}{"version": 1}},
+	})
+}
@@ -0,0 +1,211 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const xmlExtension supportedExtension = ".xml"
+
+// XMLOptions configures how XML documents are mapped to settings.
+//
+// Elements become nested keys, the root element included. Attributes become keys
+// with AttributePrefix prepended to their names. Repeated elements become lists.
+// The text of an element having attributes or children is stored under TextKey.
+// Texts and attribute values are converted to bool, int or float64 where possible.
+type XMLOptions struct {
+	AttributePrefix string
+	TextKey         string
+}
+
+// DefaultXMLOptions is the mapping used by the built-in xml format.
+var DefaultXMLOptions = XMLOptions{
+	AttributePrefix: "@",
+	TextKey:         "#text",
+}
+
+func init() {
+	RegisterXMLFormat(DefaultXMLOptions)
+}
+
+// RegisterXMLFormat registers the xml format using the given mapping options.
+// It can be called to replace the mapping of the built-in xml format.
+func RegisterXMLFormat(opts XMLOptions) {
+	formats.register(&format{
+		name:    "xml",
+		exts:    []string{string(xmlExtension)},
+		decoder: opts.decode,
+		encoder: opts.encode,
+		sniff:   opts.sniff,
+		builtin: true,
+	})
+}
+
+func (o XMLOptions) sniff(content []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		return false
+	}
+	_, err := o.decode(content)
+	return err == nil
+}
+
+func (o XMLOptions) decode(content []byte) (map[string]interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("xml: cannot find root element")
+		} else if err != nil {
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			root, err := o.decodeElement(dec, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{start.Name.Local: root}, nil
+		}
+	}
+}
+
+func (o XMLOptions) decodeElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	m := map[string]interface{}{}
+	text := strings.Builder{}
+
+	for _, attr := range start.Attr {
+		m[o.AttributePrefix+attr.Name.Local] = inferScalar(attr.Value)
+	}
+
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			child, err := o.decodeElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			addXMLChild(m, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			if len(m) == 0 {
+				return inferScalar(value), nil
+			}
+			if value != "" {
+				m[o.TextKey] = inferScalar(value)
+			}
+			return m, nil
+		}
+	}
+}
+
+func addXMLChild(m map[string]interface{}, name string, child interface{}) {
+	existing, ok := m[name]
+	if !ok {
+		m[name] = child
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		m[name] = append(list, child)
+		return
+	}
+	m[name] = []interface{}{existing, child}
+}
+
+func (o XMLOptions) encode(m map[string]interface{}) ([]byte, error) {
+	if len(m) != 1 {
+		return nil, fmt.Errorf("xml: settings should have exactly one root key, not: %d", len(m))
+	}
+
+	buf := &bytes.Buffer{}
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+
+	for name, value := range m {
+		if err := o.encodeElement(enc, name, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (o XMLOptions) encodeElement(enc *xml.Encoder, name string, value interface{}) error {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if err := o.encodeElement(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	children, ok := toStringMap(value)
+	if !ok {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(value))); err != nil {
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var elements []string
+	for _, k := range keys {
+		if o.AttributePrefix != "" && strings.HasPrefix(k, o.AttributePrefix) {
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: strings.TrimPrefix(k, o.AttributePrefix)},
+				Value: fmt.Sprint(children[k]),
+			})
+		} else if k != o.TextKey {
+			elements = append(elements, k)
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if text, ok := children[o.TextKey]; ok {
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(text))); err != nil {
+			return err
+		}
+	}
+	for _, k := range elements {
+		if err := o.encodeElement(enc, k, children[k]); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = val
+		}
+		return m, true
+	}
+	return nil, false
+}
@@ -0,0 +1,151 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitXMLSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitXMLSuite) TestLoadXML() {
+	initTestOk()
+
+	err := ioutil.WriteFile(testXMLFilePAth, []byte(testXMLContent), os.ModePerm)
+	u.Equal(nil, err)
+
+	sm := New(testYamlFilePAth).Merge(testXMLFilePAth)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("ExampleService", v)
+
+	i, err := sm.GetInt("legacy.server.port")
+	u.Equal(nil, err)
+	u.Equal(8080, i)
+
+	b, err := sm.GetBool("legacy.server.@secure")
+	u.Equal(nil, err)
+	u.Equal(true, b)
+
+	s, err := sm.GetString("legacy.server.@name")
+	u.Equal(nil, err)
+	u.Equal("primary", s)
+
+	sl, err := sm.GetStringSlice("legacy.hosts.host")
+	u.Equal(nil, err)
+	u.Equal([]string{"a.example.com", "b.example.com"}, sl)
+
+	s, err = sm.GetString("legacy.description.#text")
+	u.Equal(nil, err)
+	u.Equal("Legacy service", s)
+
+	keys, err := sm.GetAllKeys()
+	u.Equal(nil, err)
+	u.Contains(keys, "legacy.server.port")
+	u.Contains(keys, "legacy.description.@lang")
+
+	resetTest()
+}
+
+func (u unitXMLSuite) TestNewFromContentXML() {
+	sm := NewFromContent(testXMLContent)
+	u.Equal(nil, sm.Error)
+
+	f, err := sm.GetFloat64("legacy.ratio")
+	u.Equal(nil, err)
+	u.Equal(0.5, f)
+
+	sm = NewFromContent("  <a>x: y</a>")
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("a")
+	u.Equal(nil, err)
+	u.Equal("x: y", v)
+
+	sm = NewFromContent("<a>x: value")
+	u.Equal(nil, sm.Error)
+
+	v, err = sm.GetString("<a>x")
+	u.Equal(nil, err)
+	u.Equal("value", v)
+
+	sm = NewFromContentAs("<legacy><broken></legacy>", "xml")
+	u.Equal("settings.NewFromContentAs :: While parsing config: XML syntax error on line 1: element <broken> closed by </legacy>", fmt.Sprint(sm.Error))
+}
+
+func (u unitXMLSuite) TestXMLOptions() {
+	RegisterXMLFormat(XMLOptions{AttributePrefix: "attr_", TextKey: "value"})
+
+	sm := NewFromContentAs(testXMLContent, "xml")
+	u.Equal(nil, sm.Error)
+
+	b, err := sm.GetBool("legacy.server.attr_secure")
+	u.Equal(nil, err)
+	u.Equal(true, b)
+
+	s, err := sm.GetString("legacy.description.value")
+	u.Equal(nil, err)
+	u.Equal("Legacy service", s)
+
+	RegisterXMLFormat(DefaultXMLOptions)
+}
+
+func (u unitXMLSuite) TestEncodeXML() {
+	f, ok := formats.byName("xml")
+	u.Equal(true, ok)
+
+	b, err := f.encode(map[string]interface{}{
+		"legacy": map[string]interface{}{
+			"server": map[string]interface{}{"@secure": true, "port": 8080},
+			"hosts":  map[string]interface{}{"host": []interface{}{"a", "b"}},
+		},
+	})
+	u.Equal(nil, err)
+	u.Equal(`<legacy>
+  <hosts>
+    <host>a</host>
+    <host>b</host>
+  </hosts>
+  <server secure="true">
+    <port>8080</port>
+  </server>
+</legacy>`, string(b))
+
+	m, err := f.decode(b)
+	u.Equal(nil, err)
+	u.Equal(true, m["legacy"].(map[string]interface{})["server"].(map[string]interface{})["@secure"])
+
+	_, err = f.encode(map[string]interface{}{"a": 1, "b": 2})
+	u.Equal("xml: settings should have exactly one root key, not: 2", fmt.Sprint(err))
+}
+
+func TestXMLUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitXMLSuite))
+}
+
+var (
+	testXMLContent = `<?xml version="1.0" encoding="UTF-8"?>
+<legacy>
+  <server name="primary" secure="true">
+    <port>8080</port>
+  </server>
+  <hosts>
+    <host>a.example.com</host>
+    <host>b.example.com</host>
+  </hosts>
+  <ratio>0.5</ratio>
+  <description lang="en">Legacy service</description>
+</legacy>
+`
+
+	testXMLFilePAth = "./settings/legacy.xml"
+)
@@ -0,0 +1,576 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLDocument is the content of a YAML file edited by EditFile.
+// Keys are dotted paths, e.g. "server.port", the items of a sequence are addressed by their index.
+type YAMLDocument struct {
+	content []byte
+}
+
+// yamlEntry is a key and its value, the key is nil for the items of a sequence.
+type yamlEntry struct {
+	key    *yaml.Node
+	value  *yaml.Node
+	parent *yaml.Node
+}
+
+// EditFile edits a YAML settings file in place. Only the nodes touched by the edit function are changed,
+// the comments, the order of keys, the anchors and the quoting style of the rest of the file
+// are kept byte-for-byte. The file is replaced atomically, and its permissions are kept.
+// Only the first document of a multi-document stream is edited.
+func EditFile(path string, edit func(doc *YAMLDocument) error) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("settings.EditFile :: %s", err)
+	}
+
+	doc := &YAMLDocument{content: b}
+	if _, err := doc.root(); err != nil {
+		return fmt.Errorf("settings.EditFile :: %s: %s", path, err)
+	}
+	if err := edit(doc); err != nil {
+		return fmt.Errorf("settings.EditFile :: %s: %s", path, err)
+	}
+	if bytes.Equal(doc.content, b) {
+		return nil
+	}
+
+	target, perm, err := resolveFile(path)
+	if err == nil {
+		err = writeFileAtomic(target, doc.content, perm)
+	}
+	if err != nil {
+		return fmt.Errorf("settings.EditFile :: %s", err)
+	}
+	return nil
+}
+
+// Bytes returns the edited content of the document.
+func (d *YAMLDocument) Bytes() []byte {
+	return d.content
+}
+
+// Get returns the value of a key, and whether the key is found.
+func (d *YAMLDocument) Get(key string) (interface{}, bool) {
+	root, err := d.root()
+	if err != nil || root == nil {
+		return nil, false
+	}
+
+	path := strings.Split(key, ".")
+	entries := lookupYAML(root, path)
+	if len(entries) < len(path) {
+		return nil, false
+	}
+
+	var value interface{}
+	if err := entries[len(entries)-1].value.Decode(&value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set sets the value of a key. A scalar replacing a scalar is written in the quoting style of the previous one.
+// Missing keys are added after the last key of their parent mapping.
+func (d *YAMLDocument) Set(key string, value interface{}) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+	return d.edit(key, func(root *yaml.Node, path []string) error {
+		if root == nil {
+			d.appendLines(len(d.lines()), encodeYAMLEntry(path[0], nestValue(path[1:], value), ""))
+			return nil
+		}
+
+		entries := lookupYAML(root, path)
+		if len(entries) == len(path) {
+			e := entries[len(entries)-1]
+			if d.replaceScalar(e, value) {
+				return nil
+			}
+			return d.replaceEntry(e, value)
+		}
+
+		parent := root
+		if len(entries) > 0 {
+			parent = derefYAML(entries[len(entries)-1].value)
+		}
+		if parent.Kind == yaml.MappingNode {
+			return d.insertEntry(parent, path[len(entries):], value)
+		}
+		if len(entries) > 0 {
+			return d.replaceEntry(entries[len(entries)-1], nestValue(path[len(entries):], value))
+		}
+		return fmt.Errorf("%s :: cannot set key", key)
+	})
+}
+
+// Delete removes a key, or an item of a sequence. Deleting a missing key is not an error.
+func (d *YAMLDocument) Delete(key string) error {
+	return d.edit(key, func(root *yaml.Node, path []string) error {
+		if root == nil {
+			return nil
+		}
+		entries := lookupYAML(root, path)
+		if len(entries) < len(path) {
+			return nil
+		}
+		return d.deleteEntry(entries[len(entries)-1])
+	})
+}
+
+// edit runs an edit on the root node, and reverts it, if the edited content cannot be parsed.
+func (d *YAMLDocument) edit(key string, fn func(root *yaml.Node, path []string) error) error {
+	root, err := d.root()
+	if err != nil {
+		return err
+	}
+	if root != nil && root.Kind != yaml.MappingNode {
+		return errors.New("the document is not a mapping")
+	}
+
+	previous := d.content
+	if err := fn(root, strings.Split(key, ".")); err != nil {
+		d.content = previous
+		return err
+	}
+	if _, err := d.root(); err != nil {
+		d.content = previous
+		return fmt.Errorf("%s :: %s", key, err)
+	}
+	return nil
+}
+
+// root parses the first document, and returns its root node, or nil for an empty document.
+func (d *YAMLDocument) root() (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(d.content)).Decode(&doc); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	if root := doc.Content[0]; root.Kind != yaml.ScalarNode || root.Tag != "!!null" || root.Value != "" {
+		return root, nil
+	}
+	return nil, nil
+}
+
+// replaceScalar replaces a scalar in place, and reports whether it could be done.
+func (d *YAMLDocument) replaceScalar(e yamlEntry, value interface{}) bool {
+	if e.value.Kind != yaml.ScalarNode && e.value.Kind != yaml.AliasNode {
+		return false
+	}
+	flow := e.parent.Style&yaml.FlowStyle != 0
+
+	text, ok := formatYAMLScalar(value, e.value.Style, flow)
+	if !ok {
+		return false
+	}
+	start, end, ok := d.scalarRange(e.value, flow)
+	if !ok {
+		return false
+	}
+
+	d.content = append(append(append([]byte{}, d.content[:start]...), text...), d.content[end:]...)
+	return true
+}
+
+// replaceEntry replaces the lines of a key and its value with the lines of the new value.
+func (d *YAMLDocument) replaceEntry(e yamlEntry, value interface{}) error {
+	if e.key == nil || e.parent.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("%s :: cannot replace value", e.value.Value)
+	}
+	prefix, ok := d.entryPrefix(e.key)
+	if !ok {
+		return fmt.Errorf("%s :: cannot replace value", e.key.Value)
+	}
+	keyStart, keyEnd, ok := d.scalarRange(e.key, false)
+	if !ok {
+		return fmt.Errorf("%s :: cannot replace value", e.key.Value)
+	}
+
+	indent := strings.Repeat(" ", e.key.Column-1)
+	lines := encodeYAMLEntry(string(d.content[keyStart:keyEnd]), value, indent)
+	lines[0] = prefix + strings.TrimPrefix(lines[0], indent)
+
+	end := d.entryEnd(e.key.Line, e.key.Column-1, e.value.Kind == yaml.SequenceNode)
+	d.replaceLines(e.key.Line, end, lines)
+	return nil
+}
+
+// insertEntry adds the missing keys of a path after the last key of a block mapping.
+func (d *YAMLDocument) insertEntry(parent *yaml.Node, path []string, value interface{}) error {
+	if parent.Style&yaml.FlowStyle != 0 || len(parent.Content) == 0 {
+		return fmt.Errorf("%s :: cannot add key to a flow mapping", path[0])
+	}
+
+	lastKey, lastValue := parent.Content[len(parent.Content)-2], parent.Content[len(parent.Content)-1]
+	end := d.entryEnd(lastKey.Line, lastKey.Column-1, lastValue.Kind == yaml.SequenceNode)
+
+	name, _ := formatYAMLScalar(path[0], 0, false)
+	d.appendLines(end, encodeYAMLEntry(name, nestValue(path[1:], value), strings.Repeat(" ", lastKey.Column-1)))
+	return nil
+}
+
+// deleteEntry removes the lines of a key and its value, or of an item of a block sequence.
+func (d *YAMLDocument) deleteEntry(e yamlEntry) error {
+	if e.parent.Style&yaml.FlowStyle != 0 {
+		return errors.New("cannot delete from a flow collection")
+	}
+
+	if e.key == nil {
+		prefix, ok := d.entryPrefix(e.value)
+		if !ok || !strings.HasSuffix(strings.TrimRight(prefix, " "), "-") {
+			return errors.New("cannot delete item")
+		}
+		indent := len(strings.TrimRight(prefix, " ")) - 1
+		d.replaceLines(e.value.Line, d.entryEnd(e.value.Line, indent, false), nil)
+		return nil
+	}
+
+	prefix, ok := d.entryPrefix(e.key)
+	if !ok {
+		return fmt.Errorf("%s :: cannot delete key", e.key.Value)
+	}
+	end := d.entryEnd(e.key.Line, e.key.Column-1, e.value.Kind == yaml.SequenceNode)
+
+	if strings.TrimSpace(prefix) == "" {
+		d.replaceLines(e.key.Line, end, nil)
+		return nil
+	}
+
+	// The key follows the dash of a sequence item: the dash is moved to the next key of the item.
+	for i := 0; i < len(e.parent.Content); i += 2 {
+		next := e.parent.Content[i]
+		if next != e.key && next.Line > end {
+			lines := d.lines()
+			lines[next.Line-1] = prefix + lines[next.Line-1][next.Column-1:]
+			d.setLines(lines)
+			d.replaceLines(e.key.Line, end, nil)
+			return nil
+		}
+	}
+	d.replaceLines(e.key.Line, end, []string{prefix + "{}"})
+	return nil
+}
+
+// entryPrefix returns the text before a node on its line, if it holds only spaces and a sequence dash.
+func (d *YAMLDocument) entryPrefix(n *yaml.Node) (string, bool) {
+	lines := d.lines()
+	if n.Line < 1 || n.Line > len(lines) {
+		return "", false
+	}
+	line := []rune(lines[n.Line-1])
+	if n.Column-1 > len(line) {
+		return "", false
+	}
+
+	prefix := string(line[:n.Column-1])
+	if strings.Trim(prefix, " -") != "" || strings.Count(prefix, "-") > 1 {
+		return "", false
+	}
+	return prefix, true
+}
+
+// entryEnd returns the last line of an entry starting at a line: the following lines belong to it,
+// while they are indented more than the key. Trailing blank and comment lines are not part of it.
+func (d *YAMLDocument) entryEnd(line, indent int, sequence bool) int {
+	lines := d.lines()
+	end := line
+	for l := line + 1; l <= len(lines); l++ {
+		text := strings.TrimRight(lines[l-1], "\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		ind := len(text) - len(trimmed)
+		if ind > indent || (sequence && ind == indent && (trimmed == "-" || strings.HasPrefix(trimmed, "- "))) {
+			end = l
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// scalarRange returns the byte range of a single-line scalar or alias, without its anchor and tag.
+func (d *YAMLDocument) scalarRange(n *yaml.Node, flow bool) (int, int, bool) {
+	start, ok := d.offset(n.Line, n.Column)
+	if !ok {
+		return 0, 0, false
+	}
+	lineEnd := bytes.IndexByte(d.content[start:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(d.content) - start
+	}
+	text := strings.TrimRight(string(d.content[start:start+lineEnd]), "\r")
+
+	i := 0
+	for i < len(text) && (text[i] == '&' || text[i] == '!') && n.Kind != yaml.AliasNode {
+		for i < len(text) && text[i] != ' ' {
+			i++
+		}
+		for i < len(text) && text[i] == ' ' {
+			i++
+		}
+	}
+	if i >= len(text) {
+		return 0, 0, false
+	}
+
+	end := -1
+	switch text[i] {
+	case '"':
+		for j := i + 1; j < len(text); j++ {
+			if text[j] == '\\' {
+				j++
+			} else if text[j] == '"' {
+				end = j + 1
+				break
+			}
+		}
+	case '\'':
+		for j := i + 1; j < len(text); j++ {
+			if text[j] == '\'' {
+				if j+1 < len(text) && text[j+1] == '\'' {
+					j++
+					continue
+				}
+				end = j + 1
+				break
+			}
+		}
+	case '|', '>':
+		return 0, 0, false
+	default:
+		end = len(text)
+		for j := i; j < len(text); j++ {
+			if (text[j] == '#' && j > i && (text[j-1] == ' ' || text[j-1] == '\t')) ||
+				(flow && strings.IndexByte(",]}", text[j]) >= 0) ||
+				(n.Kind == yaml.ScalarNode && text[j] == ':' && (j+1 == len(text) || text[j+1] == ' ')) {
+				end = j
+				break
+			}
+		}
+		end = i + len(strings.TrimRight(text[i:end], " \t"))
+
+		token := text[i:end]
+		if n.Kind == yaml.AliasNode {
+			token = strings.TrimPrefix(token, "*")
+		}
+		if token != n.Value {
+			// A plain scalar continued on the next lines.
+			return 0, 0, false
+		}
+	}
+	if end < 0 {
+		return 0, 0, false
+	}
+	return start + i, start + end, true
+}
+
+// offset converts a line and a column, both counted from 1 in characters, to a byte offset.
+func (d *YAMLDocument) offset(line, column int) (int, bool) {
+	off := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(d.content[off:], '\n')
+		if i < 0 {
+			return 0, false
+		}
+		off += i + 1
+	}
+	for c := 1; c < column; c++ {
+		if off >= len(d.content) || d.content[off] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(d.content[off:])
+		off += size
+	}
+	return off, true
+}
+
+func (d *YAMLDocument) lines() []string {
+	lines := strings.Split(string(d.content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func (d *YAMLDocument) setLines(lines []string) {
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 && (len(d.content) == 0 || d.content[len(d.content)-1] == '\n') {
+		content += "\n"
+	}
+	d.content = []byte(content)
+}
+
+// replaceLines replaces the lines from first to last, both counted from 1.
+func (d *YAMLDocument) replaceLines(first, last int, replacement []string) {
+	lines := d.lines()
+	replacement = d.withLineBreaks(replacement)
+
+	var result []string
+	result = append(result, lines[:first-1]...)
+	result = append(result, replacement...)
+	result = append(result, lines[last:]...)
+	d.setLines(result)
+}
+
+// appendLines adds lines after a line, counted from 1.
+func (d *YAMLDocument) appendLines(after int, added []string) {
+	if len(d.content) > 0 && d.content[len(d.content)-1] != '\n' {
+		d.content = append(d.content, d.lineBreak()...)
+	}
+	lines := d.lines()
+	added = d.withLineBreaks(added)
+
+	var result []string
+	result = append(result, lines[:after]...)
+	result = append(result, added...)
+	result = append(result, lines[after:]...)
+	d.content = []byte(strings.Join(result, "\n") + "\n")
+}
+
+func (d *YAMLDocument) withLineBreaks(lines []string) []string {
+	if d.lineBreak() == "\n" {
+		return lines
+	}
+	converted := make([]string, len(lines))
+	for i, line := range lines {
+		converted[i] = line + "\r"
+	}
+	return converted
+}
+
+func (d *YAMLDocument) lineBreak() string {
+	if bytes.Contains(d.content, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// lookupYAML returns the entries along a path, as far as the path can be found.
+func lookupYAML(root *yaml.Node, path []string) []yamlEntry {
+	var entries []yamlEntry
+	node := root
+
+	for _, key := range path {
+		node = derefYAML(node)
+		var found *yamlEntry
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					found = &yamlEntry{key: node.Content[i], value: node.Content[i+1], parent: node}
+					break
+				}
+			}
+			for i := 0; found == nil && i+1 < len(node.Content); i += 2 {
+				if strings.EqualFold(node.Content[i].Value, key) {
+					found = &yamlEntry{key: node.Content[i], value: node.Content[i+1], parent: node}
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+				found = &yamlEntry{value: node.Content[i], parent: node}
+			}
+		}
+
+		if found == nil {
+			break
+		}
+		entries = append(entries, *found)
+		node = found.value
+	}
+	return entries
+}
+
+func derefYAML(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// formatYAMLScalar formats a scalar value in the given style, if it can be written on a single line.
+func formatYAMLScalar(value interface{}, style yaml.Style, flow bool) (string, bool) {
+	var n yaml.Node
+	if err := n.Encode(value); err != nil || n.Kind != yaml.ScalarNode {
+		return "", false
+	}
+
+	if n.Tag == "!!str" {
+		s := n.Value
+		switch {
+		case style&yaml.DoubleQuotedStyle != 0:
+			return strconv.Quote(s), true
+		case style&yaml.SingleQuotedStyle != 0 && !strings.ContainsAny(s, "\r\n"):
+			return "'" + strings.ReplaceAll(s, "'", "''") + "'", true
+		}
+	}
+
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	text := strings.TrimSuffix(string(out), "\n")
+	if n.Tag == "!!str" && (strings.Contains(text, "\n") || (flow && strings.ContainsAny(text, ",[]{}"))) {
+		return strconv.Quote(n.Value), true
+	}
+	if strings.Contains(text, "\n") {
+		return "", false
+	}
+	return text, true
+}
+
+// encodeYAMLEntry returns the lines of a key and its value, indented by the given indent.
+func encodeYAMLEntry(key string, value interface{}, indent string) []string {
+	if text, ok := formatYAMLScalar(value, 0, false); ok {
+		return []string{indent + key + ": " + text}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(value); err != nil {
+		return []string{indent + key + ": null"}
+	}
+	_ = enc.Close()
+
+	text := strings.TrimSuffix(buf.String(), "\n")
+	if text == "{}" || text == "[]" {
+		return []string{indent + key + ": " + text}
+	}
+
+	lines := []string{indent + key + ":"}
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, indent+"  "+line)
+	}
+	return lines
+}
+
+// nestValue nests a value under the keys of a path.
+func nestValue(path []string, value interface{}) interface{} {
+	for i := len(path) - 1; i >= 0; i-- {
+		value = map[string]interface{}{path[i]: value}
+	}
+	return value
+}
@@ -0,0 +1,198 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	unitYAMLEditSuite struct {
+		suite.Suite
+	}
+)
+
+func (u unitYAMLEditSuite) TestEditFile() {
+	initTestOk()
+
+	file := filepath.Join(testDirPath, "edit.yaml")
+	u.Equal(nil, ioutil.WriteFile(file, []byte(testYAMLEditContent), 0640))
+
+	err := EditFile(file, func(doc *YAMLDocument) error {
+		if err := doc.Set("service.name", "Edited"); err != nil {
+			return err
+		}
+		if err := doc.Set("service.port", 9090); err != nil {
+			return err
+		}
+		if err := doc.Set("service.owner", "it's"); err != nil {
+			return err
+		}
+		return doc.Set("service.tags.1", "c")
+	})
+	u.Equal(nil, err)
+
+	u.Equal(`# Service settings
+defaults: &defaults
+  timeout: 5 # seconds
+
+service:
+  name: "Edited"   # quoted
+  owner: 'it''s'
+  port: &port 9090
+  tags: [a, c]
+  <<: *defaults
+
+servers:
+  - host: a.example.com
+    port: 1
+  - host: b.example.com
+    port: 2
+`, readTestYAMLFile(u, file))
+
+	fi, err := os.Stat(file)
+	u.Equal(nil, err)
+	u.Equal(os.FileMode(0640), fi.Mode().Perm())
+
+	sm := New(file)
+	u.Equal(nil, sm.Error)
+
+	v, err := sm.GetString("service.name")
+	u.Equal(nil, err)
+	u.Equal("Edited", v)
+
+	i, err := sm.GetInt("service.timeout")
+	u.Equal(nil, err)
+	u.Equal(5, i)
+
+	err = EditFile(file, func(doc *YAMLDocument) error {
+		return errors.New("failed")
+	})
+	u.Equal(fmt.Sprintf("settings.EditFile :: %s: failed", file), fmt.Sprint(err))
+
+	err = EditFile(filepath.Join(testDirPath, "missing.yaml"), func(doc *YAMLDocument) error {
+		return nil
+	})
+	u.Equal("settings.EditFile :: open settings/missing.yaml: no such file or directory", fmt.Sprint(err))
+
+	resetTest()
+}
+
+func (u unitYAMLEditSuite) TestSet() {
+	doc := &YAMLDocument{content: []byte(testYAMLEditContent)}
+
+	u.Equal(nil, doc.Set("service.db.host", "localhost"))
+	u.Equal(nil, doc.Set("servers.1.port", []int{80, 443}))
+	u.Equal(nil, doc.Set("defaults.timeout", map[string]interface{}{"read": 1}))
+	u.Equal(nil, doc.Set("logging.level", "debug"))
+
+	u.Equal(`# Service settings
+defaults: &defaults
+  timeout:
+    read: 1
+
+service:
+  name: "ExampleService"   # quoted
+  owner: 'team'
+  port: &port 8080
+  tags: [a, b]
+  <<: *defaults
+  db:
+    host: localhost
+
+servers:
+  - host: a.example.com
+    port: 1
+  - host: b.example.com
+    port:
+      - 80
+      - 443
+logging:
+  level: debug
+`, string(doc.Bytes()))
+
+	v, ok := doc.Get("servers.1.port.0")
+	u.Equal(true, ok)
+	u.Equal(80, v)
+
+	_, ok = doc.Get("servers.2")
+	u.Equal(false, ok)
+
+	u.Equal("empty key", fmt.Sprint(doc.Set("", 1)))
+
+	doc = &YAMLDocument{content: []byte("a: {b: 1}\n")}
+	u.Equal(nil, doc.Set("a.b", 2))
+	u.Equal("c :: cannot add key to a flow mapping", fmt.Sprint(doc.Set("a.c", 1)))
+	u.Equal("a: {b: 2}\n", string(doc.Bytes()))
+
+	doc = &YAMLDocument{content: []byte("a: 1\r\nb: 2\r\n")}
+	u.Equal(nil, doc.Set("c.d", 3))
+	u.Equal("a: 1\r\nb: 2\r\nc:\r\n  d: 3\r\n", string(doc.Bytes()))
+
+	doc = &YAMLDocument{content: []byte("# empty\n")}
+	u.Equal(nil, doc.Set("a.b", "text"))
+	u.Equal("# empty\na:\n  b: text\n", string(doc.Bytes()))
+
+	doc = &YAMLDocument{content: []byte("- a\n- b\n")}
+	u.Equal("the document is not a mapping", fmt.Sprint(doc.Set("a", 1)))
+}
+
+func (u unitYAMLEditSuite) TestDelete() {
+	doc := &YAMLDocument{content: []byte(testYAMLEditContent)}
+
+	u.Equal(nil, doc.Delete("defaults.timeout"))
+	u.Equal(nil, doc.Delete("service.owner"))
+	u.Equal(nil, doc.Delete("servers.0.host"))
+	u.Equal(nil, doc.Delete("servers.1"))
+	u.Equal(nil, doc.Delete("missing.key"))
+
+	u.Equal(`# Service settings
+defaults: &defaults
+
+service:
+  name: "ExampleService"   # quoted
+  port: &port 8080
+  tags: [a, b]
+  <<: *defaults
+
+servers:
+  - port: 1
+`, string(doc.Bytes()))
+
+	u.Equal("cannot delete from a flow collection", fmt.Sprint(doc.Delete("service.tags.0")))
+}
+
+func TestYAMLEditUnitSuite(t *testing.T) {
+	suite.Run(t, new(unitYAMLEditSuite))
+}
+
+func readTestYAMLFile(u unitYAMLEditSuite, file string) string {
+	b, err := ioutil.ReadFile(file)
+	u.Equal(nil, err)
+	return string(b)
+}
+
+var (
+	testYAMLEditContent = `# Service settings
+defaults: &defaults
+  timeout: 5 # seconds
+
+service:
+  name: "ExampleService"   # quoted
+  owner: 'team'
+  port: &port 8080
+  tags: [a, b]
+  <<: *defaults
+
+servers:
+  - host: a.example.com
+    port: 1
+  - host: b.example.com
+    port: 2
+`
+)